@@ -0,0 +1,112 @@
+// Package clpsolver solves linear programs with the COIN-OR CLP simplex. Importing it
+// registers the solver under the name "clp".
+package clpsolver
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lanl/clp"
+	opt "github.com/ohowland/cgc_optimize"
+)
+
+func init() {
+	opt.RegisterSolver("clp", Solver{})
+}
+
+// Solver solves linear programs with the CLP primal simplex. CLP has no integer
+// support, so SolveMip only accepts programs without integer columns.
+type Solver struct{}
+
+func (Solver) SolveLp(w opt.LinearProgram, t_opts opt.SolverOptions) (opt.Result, error) {
+	return solve(w, t_opts)
+}
+
+func (Solver) SolveMip(w opt.MipLinearProgram, t_opts opt.SolverOptions) (opt.Result, error) {
+	for _, i := range w.Integrality() {
+		if i != 0 {
+			return opt.Result{Status: opt.StatusError}, errors.New("clp: integer columns are not supported")
+		}
+	}
+	return solve(w, t_opts)
+}
+
+func solve(w opt.LinearProgram, t_opts opt.SolverOptions) (res opt.Result, err error) {
+	if len(opt.SparseConstraintsOf(w)) == 0 {
+		return opt.Result{Status: opt.StatusError}, errors.New("clp: linear program has no constraints")
+	}
+
+	// the CLP bindings panic on malformed input, recover and report it as an error.
+	defer func() {
+		if r := recover(); r != nil {
+			res = opt.Result{Status: opt.StatusError}
+			err = fmt.Errorf("clp: %v", r)
+		}
+	}()
+
+	s := clp.NewSimplex()
+	load(s, w)
+
+	if t_opts.TimeLimit > 0 {
+		s.SetMaxSeconds(t_opts.TimeLimit.Seconds())
+	}
+
+	s.SetOptimizationDirection(clp.Minimize)
+	status := clpStatus(s.Primal(clp.NoValuesPass, clp.NoStartFinishOptions))
+	if status != opt.StatusOptimal {
+		return opt.Result{Status: status}, opt.SolveError{Status: status}
+	}
+
+	return opt.Result{
+		Status:    status,
+		Objective: s.ObjectiveValue(),
+		Columns:   s.PrimalColumnSolution(),
+		Rows:      s.PrimalRowSolution(),
+		Duals:     s.DualRowSolution(),
+	}, nil
+}
+
+// load passes w to the simplex as a column-packed sparse matrix.
+func load(s *clp.Simplex, w opt.LinearProgram) {
+	cost := w.CostCoefficients()
+	cx := opt.SparseConstraintsOf(w)
+
+	mat := clp.NewPackedMatrix()
+	start, index, value := opt.CSC(len(cost), cx)
+	for j := range cost {
+		col := make([]clp.Nonzero, 0)
+		for k := start[j]; k < start[j+1]; k++ {
+			col = append(col, clp.Nonzero{Index: index[k], Value: value[k]})
+		}
+		mat.AppendColumn(col)
+	}
+	mat.SetDimensions(len(cx), len(cost))
+
+	cb := make([]clp.Bounds, 0)
+	for _, b := range w.Bounds() {
+		cb = append(cb, clp.Bounds{Lower: b[0], Upper: b[1]})
+	}
+
+	rb := make([]clp.Bounds, 0)
+	for _, c := range cx {
+		rb = append(rb, clp.Bounds{Lower: c.Lb, Upper: c.Ub})
+	}
+
+	s.LoadProblem(mat, cb, cost, rb, nil)
+}
+
+// clpStatus maps a CLP simplex status to a solve status.
+func clpStatus(t_s clp.SimplexStatus) opt.Status {
+	switch t_s {
+	case clp.Optimal:
+		return opt.StatusOptimal
+	case clp.Infeasible:
+		return opt.StatusInfeasible
+	case clp.Unbounded:
+		return opt.StatusUnbounded
+	case 3: // stopped on iterations or time
+		return opt.StatusLimit
+	default:
+		return opt.StatusError
+	}
+}
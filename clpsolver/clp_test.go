@@ -0,0 +1,19 @@
+package clpsolver
+
+import (
+	"testing"
+
+	opt "github.com/ohowland/cgc_optimize"
+	"github.com/ohowland/cgc_optimize/solvertest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClpSolver(t *testing.T) {
+	solvertest.Run(t, Solver{})
+}
+
+func TestClpRegistered(t *testing.T) {
+	s, err := opt.NewSolver("clp")
+	assert.Nil(t, err)
+	assert.Equal(t, Solver{}, s)
+}
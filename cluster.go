@@ -1,7 +1,6 @@
 package cgc_optimize
 
 import (
-	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
@@ -9,11 +8,16 @@ import (
 
 type Cluster struct {
 	groups      []Group
-	constraints [][]float64
+	constraints []SparseConstraint
+	aux         []auxColumn
+	angles      map[int]int // bus angle column of each group in a DC network
+	networks    map[int]int // next group toward the reference bus of each DC network
 }
 
+var _ MipLinearProgram = Cluster{}
+
 func NewCluster(groups ...Group) Cluster {
-	return Cluster{groups, [][]float64{}}
+	return Cluster{groups, []SparseConstraint{}, []auxColumn{}, map[int]int{}, map[int]int{}}
 }
 
 func (cl Cluster) CostCoefficients() []float64 {
@@ -21,24 +25,25 @@ func (cl Cluster) CostCoefficients() []float64 {
 	for _, g := range cl.groups {
 		cc = append(cc, g.CostCoefficients()...)
 	}
+	for _, a := range cl.aux {
+		cc = append(cc, a.cost)
+	}
 
 	return cc
 }
 
 func (cl Cluster) Constraints() [][]float64 {
-	s := cl.ColumnSize()
-	clc := make([][]float64, 0) // Cluster Constraint
+	return densify(cl.ColumnSize(), cl.SparseConstraints())
+}
+
+// SparseConstraints returns the constraints of each group, shifted to the group's
+// columns, followed by the cluster constraints.
+func (cl Cluster) SparseConstraints() []SparseConstraint {
+	clc := make([]SparseConstraint, 0) // Cluster Constraint
 
 	i := 0
-	for _, g := range cl.groups {
-		pre := make([]float64, i+1)
-		post := make([]float64, s-i-g.ColumnSize()+1)
-		for _, gc := range g.Constraints() {
-			pre[0] = lb(gc)            // shift lower bound to index 0
-			post[len(post)-1] = ub(gc) // shift upper bound at last index
-			c := append(append(pre, cons(gc)...), post...)
-			clc = append(clc, c)
-		}
+	for k, g := range cl.groups {
+		clc = shiftConstraints(clc, g.SparseConstraints(), i, groupPrefix(k))
 		i += g.ColumnSize()
 	}
 
@@ -46,38 +51,86 @@ func (cl Cluster) Constraints() [][]float64 {
 	return clc
 }
 
+// ColumnNames returns the name of each column, qualified by group index.
+func (cl Cluster) ColumnNames() []string {
+	names := make([]string, 0)
+	for k, g := range cl.groups {
+		names = append(names, prefixNames(groupPrefix(k), g.ColumnNames())...)
+	}
+	for _, a := range cl.aux {
+		names = append(names, a.name)
+	}
+	return names
+}
+
+// ConstraintNames returns the name of each constraint row.
+func (cl Cluster) ConstraintNames() []string {
+	return constraintNames(cl.SparseConstraints())
+}
+
+// groupPrefix returns the qualifier of the names of the t_k-th group within a cluster
+func groupPrefix(t_k int) string {
+	return fmt.Sprintf("g%v.", t_k)
+}
+
 func (cl Cluster) Bounds() [][2]float64 {
 	b := make([][2]float64, 0)
 
 	for _, g := range cl.groups {
 		b = append(b, g.Bounds()...)
 	}
+	for _, a := range cl.aux {
+		b = append(b, a.bounds)
+	}
 	return b
 }
 
+// Integrality returns the integer mask of the cluster columns.
+func (cl Cluster) Integrality() []int {
+	mask := make([]int, 0)
+	for _, g := range cl.groups {
+		mask = append(mask, g.Integrality()...)
+	}
+	mask = append(mask, make([]int, len(cl.aux))...)
+	return mask
+}
+
 func (cl Cluster) ColumnSize() int {
 	var s int
 	for _, g := range cl.groups {
 		s += g.ColumnSize()
 	}
 
-	return s
+	return s + len(cl.aux)
+}
+
+// NewAuxiliaryColumn appends a continuous column to the cluster, such as a bus angle, and
+// returns its index. Auxiliary columns follow the columns of every group. The columns are
+// copied before the append, so copies of a cluster do not share them.
+func (cl *Cluster) NewAuxiliaryColumn(t_name string, t_cost float64, t_lb float64, t_ub float64) int {
+	cl.aux = append(cl.aux[:len(cl.aux):len(cl.aux)], auxColumn{t_name, t_cost, [2]float64{t_lb, t_ub}, uuid.Nil})
+	return cl.ColumnSize() - 1
 }
 
 func (cl *Cluster) NewConstraint(t_c ...[]float64) error {
-	cx := make([][]float64, 0)
-	for _, c := range t_c {
-		if len(c) != cl.ColumnSize()+2 {
-			err := fmt.Sprintf("constraint contains %v columns, expected: %v", len(c), cl.ColumnSize()+2)
-			return errors.New(err)
-		}
-		cx = append(cx, c)
+	cx, err := validateDense(cl.ColumnSize(), t_c)
+	if err != nil {
+		return err
 	}
 
 	cl.constraints = append(cl.constraints, cx...)
 	return nil
 }
 
+func (cl *Cluster) NewSparseConstraint(t_c ...SparseConstraint) error {
+	if err := validateSparse(cl.ColumnSize(), t_c); err != nil {
+		return err
+	}
+
+	cl.constraints = append(cl.constraints, t_c...)
+	return nil
+}
+
 func (cl Cluster) RealPositivePowerPidLoc(t_pid uuid.UUID) []int {
 	loc := make([]int, 0)
 	i := 0
@@ -117,27 +170,48 @@ func (cl Cluster) StoredEnergyPidLoc(t_pid uuid.UUID) []int {
 	return loc
 }
 
+// RealCapacityPidLoc returns the real capacity columns of each unit with PID t_pid.
+func (cl Cluster) RealCapacityPidLoc(t_pid uuid.UUID) []int {
+	return cl.PidLoc(t_pid, realCapacityLoc)
+}
+
+// PidLoc returns the columns selected by t_loc of each unit with PID t_pid.
+func (cl Cluster) PidLoc(t_pid uuid.UUID, t_loc func(Unit) []int) []int {
+	loc := make([]int, 0)
+	i := 0
+	for _, g := range cl.groups {
+		for _, p := range g.PidLoc(t_pid, t_loc) {
+			loc = append(loc, p+i)
+		}
+		i += g.ColumnSize()
+	}
+
+	return loc
+}
+
 // Cluster Specific Constraints
 
-func LinkedBusConstraints(t_cl *Cluster, t_pid uuid.UUID) [][]float64 {
+// LinkedBusConstraints returns constraints of the form: Xp_a - Xp_b = 0 and
+// Xn_a - Xn_b = 0, sharing the dispatch of unit t_pid between the two groups a and b
+// containing it. It returns no constraints if the unit is not in exactly two groups; use
+// Cluster.LinkBus to report the error instead.
+func LinkedBusConstraints(t_cl *Cluster, t_pid uuid.UUID) []SparseConstraint {
 	pLoc := t_cl.RealPositivePowerPidLoc(t_pid) // location of Positive Real Power decision variables
 	nLoc := t_cl.RealNegativePowerPidLoc(t_pid) // location of Negative Real Power decision variables
 
 	if len(pLoc) != 2 || len(nLoc) != 2 {
-		return [][]float64{}
+		return []SparseConstraint{}
 	}
 
-	pc := make([]float64, t_cl.ColumnSize())
-	pc[pLoc[0]] = 1
-	pc[pLoc[1]] = -1
-	pc = boundConstraint(pc, 0, 0)
+	pc := NewSparseConstraint(0, 0).Named(t_pid.String() + ".linked_bus_positive")
+	pc.Add(pLoc[0], 1)
+	pc.Add(pLoc[1], -1)
 
-	nc := make([]float64, t_cl.ColumnSize())
-	nc[nLoc[0]] = 1
-	nc[nLoc[1]] = -1
-	nc = boundConstraint(nc, 0, 0)
+	nc := NewSparseConstraint(0, 0).Named(t_pid.String() + ".linked_bus_negative")
+	nc.Add(nLoc[0], 1)
+	nc.Add(nLoc[1], -1)
 
-	c := make([][]float64, 0)
+	c := make([]SparseConstraint, 0)
 	c = append(append(c, pc), nc)
 
 	return c
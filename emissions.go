@@ -0,0 +1,99 @@
+package cgc_optimize
+
+import (
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// EmissionFactor is the emissions of a unit, such as kg of CO2.
+//
+// Power: Emissions per unit of real positive power for one time step, the emissions per
+// unit of energy times the length of the time step
+// StartUp: Emissions of each start up, units with commitment decisions only
+type EmissionFactor struct {
+	Power   float64
+	StartUp float64
+}
+
+// SetEmissionFactor sets the emission factor of unit t_pid at every time step of the
+// series, replacing any factor already set. The factors are copied before the write, as
+// the profiles of SetCostProfile are.
+func (se *Series) SetEmissionFactor(t_pid uuid.UUID, t_f EmissionFactor) {
+	emissions := make(map[uuid.UUID]EmissionFactor, len(se.emissions)+1)
+	for pid, f := range se.emissions {
+		emissions[pid] = f
+	}
+	emissions[t_pid] = t_f
+	se.emissions = emissions
+}
+
+// emitters returns the PIDs of the units with an emission factor, in a stable order.
+func (se Series) emitters() []uuid.UUID {
+	px := make([]uuid.UUID, 0, len(se.emissions))
+	for pid := range se.emissions {
+		px = append(px, pid)
+	}
+	sort.Slice(px, func(i, j int) bool { return px[i].String() < px[j].String() })
+	return px
+}
+
+// stepEmissions returns the emissions of unit t_pid at the t_k-th time step of the series.
+// A unit linked across groups is counted once, by its columns in the first group.
+func (se Series) stepEmissions(t_k int, t_pid uuid.UUID) ObjectiveTerm {
+	f := se.emissions[t_pid]
+	loc := se.stepLocator(t_k)
+
+	o := NewObjectiveTerm("emissions")
+	if f.Power != 0 {
+		for _, i := range loc(t_pid, firstLoc(realPositivePowerLoc)) {
+			o.Add(i, f.Power)
+		}
+	}
+	if f.StartUp != 0 {
+		for _, i := range loc(t_pid, firstLoc(startUpLoc)) {
+			o.Add(i, f.StartUp)
+		}
+	}
+	return o
+}
+
+// firstLoc selects the columns of t_loc of the first unit it is called with only.
+func firstLoc(t_loc func(Unit) []int) func(Unit) []int {
+	called := false
+	return func(u Unit) []int {
+		if called {
+			return []int{}
+		}
+		called = true
+		return t_loc(u)
+	}
+}
+
+// EmissionsTerm returns the total emissions of the series as an objective term:
+// Sum_t(Sum_i(Fp_i * Xp_i,t + Fs_i * Xsu_i,t)) over the units with an emission factor.
+func EmissionsTerm(t_se *Series) ObjectiveTerm {
+	o := NewObjectiveTerm("emissions")
+	for k := range t_se.clusters {
+		for _, pid := range t_se.emitters() {
+			e := t_se.stepEmissions(k, pid)
+			for n, i := range e.Index {
+				o.Add(i, e.Value[n])
+			}
+		}
+	}
+	return o
+}
+
+// EmissionsCapConstraint returns a constraint of the form:
+// Sum_t(Sum_i(Fp_i * Xp_i,t + Fs_i * Xsu_i,t)) <= t_cap, limiting the total emissions of
+// the series.
+func EmissionsCapConstraint(t_se *Series, t_cap float64) SparseConstraint {
+	e := EmissionsTerm(t_se)
+	c := NewSparseConstraint(math.Inf(-1), t_cap).Named("emissions_cap")
+	for n, i := range e.Index {
+		c.Add(i, e.Value[n])
+	}
+	return c
+}
@@ -0,0 +1,91 @@
+package cgc_optimize
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEmissionsSeries(t *testing.T) (Series, uuid.UUID, uuid.UUID) {
+	genPID, _ := uuid.NewUUID()
+	cleanPID, _ := uuid.NewUUID()
+	gen := NewGeneratorUnit(genPID, 1, 0, 0, 0, 0, 0, 10)
+	clean := NewBasicUnit(cleanPID, 5, 0, 0, 0, 10, 0, 0, 0)
+	g := NewGroup(&gen, &clean)
+	err := g.NewSparseConstraint(NetLoadConstraint(&g, 6))
+	assert.Nil(t, err)
+	cl := NewCluster(g)
+
+	se := NewSeries(&cl, &cl)
+	err = se.NewSparseConstraint(GeneratorTransitionConstraints(&se, genPID)...)
+	assert.Nil(t, err)
+	err = se.NewSparseConstraint(GeneratorInitialStatusConstraint(&se, genPID, false))
+	assert.Nil(t, err)
+	se.SetEmissionFactor(genPID, EmissionFactor{Power: 1, StartUp: 4})
+	se.SetEmissionFactor(cleanPID, EmissionFactor{})
+	return se, genPID, cleanPID
+}
+
+func TestEmissionsTerm(t *testing.T) {
+	se, genPID, _ := newTestEmissionsSeries(t)
+	o := EmissionsTerm(&se)
+	assert.Equal(t, "emissions", o.Name)
+
+	pLoc := se.RealPositivePowerPidLoc(genPID)
+	suLoc := se.PidLoc(genPID, startUpLoc)
+	x := make([]float64, se.ColumnSize())
+	x[pLoc[0]], x[pLoc[1]], x[suLoc[0]] = 6, 2, 1
+	assert.Equal(t, 12.0, o.Evaluate(x))
+	assert.Len(t, o.Index, 4)
+
+	c := EmissionsCapConstraint(&se, 10)
+	assert.Equal(t, "emissions_cap", c.Name)
+	assert.Equal(t, 10.0, c.Ub)
+	assert.Equal(t, o.Index, c.Index)
+	assert.Equal(t, o.Value, c.Value)
+}
+
+func TestEmissionsCapSolve(t *testing.T) {
+	se, genPID, cleanPID := newTestEmissionsSeries(t)
+	res, err := NativeSolver{}.SolveMip(se, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 12, res.Objective, 1e-6)
+
+	srx, err := se.Decode(res.Columns)
+	assert.Nil(t, err)
+	gen := UnitSeries(srx, genPID)
+	assert.InDelta(t, 6+4, gen[0].Emissions, 1e-6)
+	assert.InDelta(t, 6, gen[1].Emissions, 1e-6)
+	assert.Equal(t, 0.0, UnitSeries(srx, cleanPID)[0].Emissions)
+
+	// the cap leaves 6 kWh for the generator after its start up
+	err = se.NewSparseConstraint(EmissionsCapConstraint(&se, 10))
+	assert.Nil(t, err)
+	res, err = NativeSolver{}.SolveMip(se, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 6+5*6, res.Objective, 1e-6)
+
+	srx, err = se.Decode(res.Columns)
+	assert.Nil(t, err)
+	gen = UnitSeries(srx, genPID)
+	assert.InDelta(t, 10, gen[0].Emissions+gen[1].Emissions, 1e-6)
+}
+
+func TestEmissionsObjective(t *testing.T) {
+	se, genPID, _ := newTestEmissionsSeries(t)
+
+	// a carbon price of 5 per unit makes the clean unit the cheaper source
+	o := NewObjective(&se)
+	assert.Nil(t, o.AddTerm(CostTerm("cost", &se), 1, 0))
+	assert.Nil(t, o.AddTerm(EmissionsTerm(&se), 5, 0))
+	res, err := NativeSolver{}.SolveMip(o, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 60, res.Objective, 1e-6)
+
+	srx, err := se.Decode(res.Columns)
+	assert.Nil(t, err)
+	for _, r := range UnitSeries(srx, genPID) {
+		assert.InDelta(t, 0, r.Emissions, 1e-6)
+	}
+}
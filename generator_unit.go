@@ -0,0 +1,196 @@
+package cgc_optimize
+
+import (
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// CommitmentLoc is implemented by units with on/off commitment decisions.
+type CommitmentLoc interface {
+	OnlineLoc() []int
+	StartUpLoc() []int
+	ShutDownLoc() []int
+}
+
+type GeneratorUnit struct {
+	pid          uuid.UUID
+	coefficients []float64
+	bounds       [][2]float64
+	constraints  []SparseConstraint
+	xpMin        float64
+}
+
+var _ Unit = GeneratorUnit{}
+var _ CommitmentLoc = GeneratorUnit{}
+
+// NewGeneratorUnit returns a configured generator unit struct. The unit has a binary
+// online column, and start up and shut down columns set by the series transition
+// constraints, which may not both be set in one time step. While online, real positive
+// power is held between XpMin and XpMax.
+//
+// Cp: Cost coefficient for real positive power
+// Cc: Cost coefficient for real capacity
+// Con: Cost of being online for a time step
+// Csu: Cost of a start up
+// Csd: Cost of a shut down
+//
+// XpMin: Minimum stable real positive power while online
+// XpMax: Maximum real positive power
+func NewGeneratorUnit(pid uuid.UUID, Cp float64, Cc float64, Con float64, Csu float64, Csd float64, XpMin float64, XpMax float64) GeneratorUnit {
+	coefficients := []float64{Cp, Cc, Con, Csu, Csd}
+	bounds := [][2]float64{{0, XpMax}, {0, XpMax}, {0, 1}, {0, 1}, {0, 1}}
+
+	// XpMin*on <= xp <= XpMax*on
+	minOutput := NewSparseConstraint(0, math.Inf(1)).Named("min_output")
+	minOutput.Add(0, 1)
+	minOutput.Add(2, -XpMin)
+
+	maxOutput := NewSparseConstraint(math.Inf(-1), 0).Named("max_output")
+	maxOutput.Add(0, 1)
+	maxOutput.Add(2, -XpMax)
+
+	// su + sd <= 1
+	startStop := NewSparseConstraint(math.Inf(-1), 1).Named("start_stop")
+	startStop.Add(3, 1)
+	startStop.Add(4, 1)
+
+	return GeneratorUnit{pid, coefficients, bounds, []SparseConstraint{minOutput, maxOutput, startStop}, XpMin}
+}
+
+func (u GeneratorUnit) PID() uuid.UUID {
+	return u.pid
+}
+
+func (u GeneratorUnit) CostCoefficients() []float64 {
+	return u.coefficients
+}
+
+func (u GeneratorUnit) ColumnSize() int {
+	return len(u.coefficients)
+}
+
+// ColumnNames returns the name of each column: xp, xc, on, su and sd
+func (u GeneratorUnit) ColumnNames() []string {
+	return []string{"xp", "xc", "on", "su", "sd"}
+}
+
+// Integrality returns the integer mask of the unit columns. Only the online column is
+// integer; start up and shut down follow from the transition constraints.
+func (u GeneratorUnit) Integrality() []int {
+	return []int{0, 0, 1, 0, 0}
+}
+
+func (u *GeneratorUnit) NewConstraint(t_c ...[]float64) error {
+	cx, err := validateDense(u.ColumnSize(), t_c)
+	if err != nil {
+		return err
+	}
+
+	// if no errors: add constraints to unit
+	u.constraints = append(u.constraints, cx...)
+	return nil
+}
+
+func (u *GeneratorUnit) NewSparseConstraint(t_c ...SparseConstraint) error {
+	if err := validateSparse(u.ColumnSize(), t_c); err != nil {
+		return err
+	}
+
+	u.constraints = append(u.constraints, t_c...)
+	return nil
+}
+
+func (u GeneratorUnit) Constraints() [][]float64 {
+	return densify(u.ColumnSize(), u.constraints)
+}
+
+func (u GeneratorUnit) SparseConstraints() []SparseConstraint {
+	return u.constraints
+}
+
+func (u GeneratorUnit) Bounds() [][2]float64 {
+	return u.bounds
+}
+
+func (u GeneratorUnit) RealPositivePowerLoc() []int {
+	return []int{0}
+}
+
+func (u GeneratorUnit) RealNegativePowerLoc() []int {
+	return []int{}
+}
+
+func (u GeneratorUnit) RealCapacityLoc() []int {
+	return []int{1}
+}
+
+func (u GeneratorUnit) StoredEnergyLoc() []int {
+	return []int{}
+}
+
+func (u GeneratorUnit) OnlineLoc() []int {
+	return []int{2}
+}
+
+func (u GeneratorUnit) StartUpLoc() []int {
+	return []int{3}
+}
+
+func (u GeneratorUnit) ShutDownLoc() []int {
+	return []int{4}
+}
+
+// MinimumOutput returns XpMin, the minimum stable real positive power while online.
+func (u GeneratorUnit) MinimumOutput() float64 {
+	return u.xpMin
+}
+
+// minimumOutputer is implemented by units held at a minimum output while online
+type minimumOutputer interface {
+	CommitmentLoc
+	MinimumOutput() float64
+}
+
+// onlineLoc, startUpLoc and shutDownLoc select the commitment columns of u, empty if u
+// has no commitment decisions.
+func onlineLoc(u Unit) []int {
+	if cu, ok := u.(CommitmentLoc); ok {
+		return cu.OnlineLoc()
+	}
+	return []int{}
+}
+
+func startUpLoc(u Unit) []int {
+	if cu, ok := u.(CommitmentLoc); ok {
+		return cu.StartUpLoc()
+	}
+	return []int{}
+}
+
+func shutDownLoc(u Unit) []int {
+	if cu, ok := u.(CommitmentLoc); ok {
+		return cu.ShutDownLoc()
+	}
+	return []int{}
+}
+
+// Constraints
+
+// GeneratorUnitCapacityConstraints returns constraints of the form: Xp <= Xc and
+// Xc <= XpMax * on, so only an online generator offers capacity
+func GeneratorUnitCapacityConstraints(u *GeneratorUnit) []SparseConstraint {
+	xp := u.RealPositivePowerLoc()[0]
+	xc := u.RealCapacityLoc()[0]
+	on := u.OnlineLoc()[0]
+
+	cp := NewSparseConstraint(0, math.Inf(1)).Named("positive_capacity")
+	cp.Add(xp, -1)
+	cp.Add(xc, 1)
+
+	co := NewSparseConstraint(math.Inf(-1), 0).Named("online_capacity")
+	co.Add(xc, 1)
+	co.Add(on, -u.bounds[xc][1])
+
+	return []SparseConstraint{cp, co}
+}
@@ -0,0 +1,197 @@
+package cgc_optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewGeneratorUnit(t *testing.T) {
+	pid, _ := uuid.NewUUID()
+	u := NewGeneratorUnit(pid, 1, 0.1, 2, 50, 10, 5, 20)
+
+	inf := math.Inf(1)
+	assert.Equal(t, []float64{1, 0.1, 2, 50, 10}, u.CostCoefficients())
+	assert.Equal(t, [][2]float64{{0, 20}, {0, 20}, {0, 1}, {0, 1}, {0, 1}}, u.Bounds())
+	assert.Equal(t, []int{0, 0, 1, 0, 0}, u.Integrality())
+	assert.Equal(t, []string{"xp", "xc", "on", "su", "sd"}, u.ColumnNames())
+	assert.Empty(t, u.RealNegativePowerLoc())
+
+	uc := u.Constraints()
+	assert.Len(t, uc, 3)
+	assert.Equal(t, []float64{0, 1, 0, -5, 0, 0, inf}, uc[0], "min output constraint malformed")
+	assert.Equal(t, []float64{-inf, 1, 0, -20, 0, 0, 0}, uc[1], "max output constraint malformed")
+	assert.Equal(t, []float64{-inf, 0, 0, 0, 1, 1, 1}, uc[2], "start stop constraint malformed")
+	assert.Equal(t, 5.0, u.MinimumOutput())
+}
+
+func TestGeneratorUnitCapacityConstraints(t *testing.T) {
+	pid, _ := uuid.NewUUID()
+	u := NewGeneratorUnit(pid, 1, 0.1, 2, 50, 10, 5, 20)
+	err := u.NewSparseConstraint(GeneratorUnitCapacityConstraints(&u)...)
+	assert.Nil(t, err)
+
+	inf := math.Inf(1)
+	uc := u.Constraints()
+	assert.Equal(t, []float64{0, -1, 1, 0, 0, 0, inf}, uc[3])
+	assert.Equal(t, []float64{-inf, 0, 1, -20, 0, 0, 0}, uc[4])
+}
+
+func TestSeriesCommitmentPidLoc(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	g := NewGroup(NewTestBasicUnit(), NewGeneratorUnit(pid1, 1, 0, 0, 0, 0, 0, 10))
+	s := NewSeries(NewCluster(g), NewCluster(g))
+
+	assert.Equal(t, []int{6, 15}, s.OnlinePidLoc(pid1))
+	assert.Equal(t, []int{7, 16}, s.StartUpPidLoc(pid1))
+	assert.Equal(t, []int{8, 17}, s.ShutDownPidLoc(pid1))
+	assert.Equal(t, s.RealPositivePowerPidLoc(pid1), s.PidLoc(pid1, func(u Unit) []int { return u.RealPositivePowerLoc() }))
+}
+
+func TestSeriesGeneratorConstraints(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	g := NewGroup(NewGeneratorUnit(pid1, 1, 0, 0, 0, 0, 0, 10))
+	s := NewSeries(g, g, g)
+	n := s.ColumnSize()
+
+	inf := math.Inf(1)
+	ic := GeneratorInitialStatusConstraint(&s, pid1, true)
+	assert.Equal(t, []float64{1, 0, 0, 1, -1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}, ic.Dense(n))
+
+	tc := GeneratorTransitionConstraints(&s, pid1)
+	assert.Len(t, tc, 2)
+	assert.Equal(t, []float64{0, 0, 0, -1, 0, 0, 0, 0, 1, -1, 1, 0, 0, 0, 0, 0, 0}, tc[0].Dense(n))
+
+	uc := GeneratorMinimumUpTimeConstraints(&s, pid1, 2)
+	assert.Len(t, uc, 3)
+	assert.Equal(t, []float64{-inf, 0, 0, 0, 1, 0, 0, 0, -1, 1, 0, 0, 0, 0, 0, 0, 0}, uc[1].Dense(n))
+	assert.Equal(t, []float64{-inf, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, -1, 1, 0, 0}, uc[2].Dense(n))
+
+	dc := GeneratorMinimumDownTimeConstraints(&s, pid1, 2)
+	assert.Len(t, dc, 3)
+	assert.Equal(t, []float64{-inf, 0, 0, 1, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}, dc[0].Dense(n))
+	assert.Equal(t, []float64{-inf, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 1, 0, 1, 1}, dc[2].Dense(n))
+}
+
+// newTestCommitmentSeries returns a series of a generator and an expensive grid serving
+// t_nl, with the generator initially offline.
+func newTestCommitmentSeries(t *testing.T, t_nl []float64) (Series, uuid.UUID) {
+	pid1, _ := uuid.NewUUID()
+	pid2, _ := uuid.NewUUID()
+	inf := math.Inf(1)
+	gen := NewGeneratorUnit(pid1, 1, 0, 1, 0, 0, 5, 10)
+	grid := NewBasicUnit(pid2, 100, 0, 0, 0, inf, inf, 0, 0)
+
+	clx := make([]Sequencer, 0)
+	for _, nl := range t_nl {
+		g := NewGroup(gen, grid)
+		err := g.NewSparseConstraint(NetLoadConstraint(&g, nl))
+		assert.Nil(t, err)
+		clx = append(clx, g)
+	}
+
+	s := NewSeries(clx...)
+	err := s.NewSparseConstraint(GeneratorInitialStatusConstraint(&s, pid1, false))
+	assert.Nil(t, err)
+	err = s.NewSparseConstraint(GeneratorTransitionConstraints(&s, pid1)...)
+	assert.Nil(t, err)
+	return s, pid1
+}
+
+func TestSeriesGeneratorMinimumUpTimeSolve(t *testing.T) {
+	s, _ := newTestCommitmentSeries(t, []float64{8, 0, 0, 0})
+	res, err := NativeSolver{}.SolveMip(s, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 9, res.Objective, 1e-6)
+
+	s, pid := newTestCommitmentSeries(t, []float64{8, 0, 0, 0})
+	err = s.NewSparseConstraint(GeneratorMinimumUpTimeConstraints(&s, pid, 3)...)
+	assert.Nil(t, err)
+	res, err = NativeSolver{}.SolveMip(s, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 21, res.Objective, 1e-6)
+
+	srx, err := s.Decode(res.Columns)
+	assert.Nil(t, err)
+	online := make([]float64, 0)
+	for _, r := range UnitSeries(srx, pid) {
+		online = append(online, r.Online)
+	}
+	assert.InDeltaSlice(t, []float64{1, 1, 1, 0}, online, 1e-6)
+}
+
+func TestSeriesGeneratorMinimumDownTimeSolve(t *testing.T) {
+	s, _ := newTestCommitmentSeries(t, []float64{8, 0, 8, 8})
+	res, err := NativeSolver{}.SolveMip(s, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 27, res.Objective, 1e-6)
+
+	s, pid := newTestCommitmentSeries(t, []float64{8, 0, 8, 8})
+	err = s.NewSparseConstraint(GeneratorMinimumDownTimeConstraints(&s, pid, 2)...)
+	assert.Nil(t, err)
+	res, err = NativeSolver{}.SolveMip(s, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 33, res.Objective, 1e-6)
+}
+
+func TestSeriesGeneratorInitialTimeSolve(t *testing.T) {
+	s, pid := newTestCommitmentSeries(t, []float64{8, 8, 8, 8})
+	n := s.ColumnSize()
+	inf := math.Inf(1)
+
+	ux := GeneratorInitialUpTimeConstraints(&s, pid, 3, 1)
+	assert.Len(t, ux, 2)
+	assert.Equal(t, pid.String()+".initial_min_up.t1", ux[1].Name)
+	assert.Equal(t, 1.0, ux[1].Dense(n)[0])
+	assert.Equal(t, 1.0, ux[1].Dense(n)[1+s.OnlinePidLoc(pid)[1]])
+	assert.Empty(t, GeneratorInitialUpTimeConstraints(&s, pid, 3, 3))
+	assert.Len(t, GeneratorInitialUpTimeConstraints(&s, pid, 9, 0), 4)
+
+	// shut down one step before the series, the generator stays offline for two more
+	dx := GeneratorInitialDownTimeConstraints(&s, pid, 3, 1)
+	assert.Len(t, dx, 2)
+	assert.Equal(t, inf, -dx[0].Dense(n)[0])
+	err := s.NewSparseConstraint(dx...)
+	assert.Nil(t, err)
+	res, err := NativeSolver{}.SolveMip(s, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 2*800+2*9, res.Objective, 1e-6)
+}
+
+func TestSeriesGeneratorRampSolve(t *testing.T) {
+	pid, _ := uuid.NewUUID()
+	gridPID, _ := uuid.NewUUID()
+	gen := NewGeneratorUnit(pid, 1, 0, 1, 0, 0, 5, 10)
+	grid := NewBasicUnit(gridPID, 100, 0, 0, 0, math.Inf(1), 0, 0, 0)
+
+	clx := make([]Sequencer, 0)
+	for _, nl := range []float64{0, 8, 0} {
+		g := NewGroup(gen, grid)
+		err := g.NewSparseConstraint(NetLoadConstraint(&g, nl))
+		assert.Nil(t, err)
+		clx = append(clx, g)
+	}
+	s := NewSeries(clx...)
+	assert.Nil(t, s.NewSparseConstraint(GeneratorInitialStatusConstraint(&s, pid, false)))
+	assert.Nil(t, s.NewSparseConstraint(GeneratorTransitionConstraints(&s, pid)...))
+	assert.Nil(t, s.NewSparseConstraint(InitialRampRateConstraint(&s, pid, 2, 2, 1, 0)))
+
+	rx := RampRateConstraints(&s, pid, 2, 2, 1)
+	assert.Equal(t, -5.0, rx[0].Dense(s.ColumnSize())[1+s.StartUpPidLoc(pid)[1]])
+	assert.Equal(t, 5.0, rx[0].Dense(s.ColumnSize())[1+s.ShutDownPidLoc(pid)[1]])
+	assert.Nil(t, s.NewSparseConstraint(rx...))
+
+	// starting up, the generator ramps to its minimum output and a further 2, and shuts
+	// down from there
+	res, err := NativeSolver{}.SolveMip(s, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 7+1+100, res.Objective, 1e-6)
+
+	srx, err := s.Decode(res.Columns)
+	assert.Nil(t, err)
+	ux := UnitSeries(srx, pid)
+	assert.InDelta(t, 7, ux[1].RealPositivePower, 1e-6)
+	assert.InDelta(t, 0, ux[2].Online, 1e-6)
+}
@@ -7,4 +7,5 @@ require (
 	github.com/lanl/clp v1.1.0
 	github.com/ohowland/highs v0.0.0-20210522162651-b9b4a0166e65
 	github.com/stretchr/testify v1.7.0
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
 )
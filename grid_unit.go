@@ -0,0 +1,203 @@
+package cgc_optimize
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// GridUnit is the point of common coupling with the utility. Import is real positive
+// power and export real negative power, so the unit balances the NetLoadConstraint of
+// its group like any other source. When the export price exceeds the import price the
+// solve profits from importing and exporting at once; EnableDirection forbids it.
+type GridUnit struct {
+	pid          uuid.UUID
+	coefficients []float64
+	bounds       [][2]float64
+	constraints  []SparseConstraint
+	direction    bool
+}
+
+var _ Unit = GridUnit{}
+
+// NewGridUnit returns a configured grid unit struct. Prices that vary by time step are
+// applied to the series with SetCostProfile at RealPositivePowerPidLoc (import) and
+// RealNegativePowerPidLoc (export, as the negated price).
+//
+// Ci: Price of imported energy
+// Ce: Price paid for exported energy
+//
+// XiUb: Import limit
+// XeUb: Export limit (positive value)
+func NewGridUnit(pid uuid.UUID, Ci float64, Ce float64, XiUb float64, XeUb float64) GridUnit {
+	coefficients := []float64{Ci, -Ce}
+	bounds := [][2]float64{{0, XiUb}, {0, XeUb}}
+
+	return GridUnit{pid, coefficients, bounds, []SparseConstraint{}, false}
+}
+
+func (u GridUnit) PID() uuid.UUID {
+	return u.pid
+}
+
+func (u GridUnit) CostCoefficients() []float64 {
+	return u.coefficients
+}
+
+func (u GridUnit) ColumnSize() int {
+	return len(u.coefficients)
+}
+
+// ColumnNames returns the name of each column: xi, xe and, with direction enabled, xd
+func (u GridUnit) ColumnNames() []string {
+	if u.direction {
+		return []string{"xi", "xe", "xd"}
+	}
+	return []string{"xi", "xe"}
+}
+
+// Integrality returns the integer mask of the unit columns. Only the direction column
+// is integer.
+func (u GridUnit) Integrality() []int {
+	mask := make([]int, u.ColumnSize())
+	for _, i := range u.DirectionLoc() {
+		mask[i] = 1
+	}
+	return mask
+}
+
+// EnableDirection adds a binary direction column, Xd, and constraints that allow only
+// one of import and export to be nonzero:
+//
+// Xi <= XiUb * Xd
+// Xe <= XeUb * (1 - Xd)
+//
+// The import and export limits serve as big-M, so both must be finite, and a series may
+// not raise them with SetUpperBoundProfile. The unit must then be solved as a MIP.
+// Enabling direction twice has no further effect.
+func (u *GridUnit) EnableDirection() error {
+	if u.direction {
+		return nil
+	}
+
+	xiUb := u.bounds[u.RealPositivePowerLoc()[0]][1]
+	xeUb := u.bounds[u.RealNegativePowerLoc()[0]][1]
+	if math.IsInf(xiUb, 1) || math.IsInf(xeUb, 1) {
+		err := fmt.Sprintf("direction requires finite import and export limits, found XiUb: %v, XeUb: %v", xiUb, xeUb)
+		return errors.New(err)
+	}
+
+	u.direction = true
+	u.coefficients = append(u.coefficients, 0)
+	u.bounds = append(u.bounds, [2]float64{0, 1})
+	u.constraints = append(u.constraints, GridUnitDirectionConstraints(u)...)
+	return nil
+}
+
+func (u *GridUnit) NewConstraint(t_c ...[]float64) error {
+	cx, err := validateDense(u.ColumnSize(), t_c)
+	if err != nil {
+		return err
+	}
+
+	// if no errors: add constraints to unit
+	u.constraints = append(u.constraints, cx...)
+	return nil
+}
+
+func (u *GridUnit) NewSparseConstraint(t_c ...SparseConstraint) error {
+	if err := validateSparse(u.ColumnSize(), t_c); err != nil {
+		return err
+	}
+
+	u.constraints = append(u.constraints, t_c...)
+	return nil
+}
+
+func (u GridUnit) Constraints() [][]float64 {
+	return densify(u.ColumnSize(), u.constraints)
+}
+
+func (u GridUnit) SparseConstraints() []SparseConstraint {
+	return u.constraints
+}
+
+func (u GridUnit) Bounds() [][2]float64 {
+	return u.bounds
+}
+
+func (u GridUnit) RealPositivePowerLoc() []int {
+	return []int{0}
+}
+
+func (u GridUnit) RealNegativePowerLoc() []int {
+	return []int{1}
+}
+
+func (u GridUnit) RealCapacityLoc() []int {
+	return []int{}
+}
+
+func (u GridUnit) StoredEnergyLoc() []int {
+	return []int{}
+}
+
+// DirectionLoc returns the location of the direction column, empty unless direction is
+// enabled.
+func (u GridUnit) DirectionLoc() []int {
+	if u.direction {
+		return []int{2}
+	}
+	return []int{}
+}
+
+// directionLimits returns the big-M of the import and export columns, empty unless
+// direction is enabled.
+func (u GridUnit) directionLimits() map[int]float64 {
+	m := make(map[int]float64)
+	if u.direction {
+		for _, i := range append(u.RealPositivePowerLoc(), u.RealNegativePowerLoc()...) {
+			m[i] = u.bounds[i][1]
+		}
+	}
+	return m
+}
+
+// Constraints
+
+// GridUnitDirectionConstraints returns constraints of the form: Xi - XiUb*Xd <= 0 and
+// Xe + XeUb*Xd <= XeUb. Direction must be enabled on the unit.
+func GridUnitDirectionConstraints(u *GridUnit) []SparseConstraint {
+	xi := u.RealPositivePowerLoc()[0]
+	xe := u.RealNegativePowerLoc()[0]
+	xd := u.DirectionLoc()[0]
+	xiUb := u.bounds[xi][1]
+	xeUb := u.bounds[xe][1]
+
+	ci := NewSparseConstraint(math.Inf(-1), 0).Named("direction_import")
+	ci.Add(xi, 1)
+	ci.Add(xd, -xiUb)
+
+	ce := NewSparseConstraint(math.Inf(-1), xeUb).Named("direction_export")
+	ce.Add(xe, 1)
+	ce.Add(xd, xeUb)
+
+	return []SparseConstraint{ci, ce}
+}
+
+// GridDemandChargeConstraints returns constraints of the form: Xi_t - peak <= 0, holding
+// the import of unit t_pid at every time step below the series column t_peak. With the
+// demand charge as the cost of t_peak, the solve minimizes the billed peak. A peak already
+// set in the billing period is applied as the lower bound of t_peak.
+func GridDemandChargeConstraints(t_se *Series, t_pid uuid.UUID, t_peak int) []SparseConstraint {
+	cx := make([]SparseConstraint, 0)
+	for i, p := range t_se.RealPositivePowerPidLoc(t_pid) {
+		c := NewSparseConstraint(math.Inf(-1), 0).Named(fmt.Sprintf("%v.demand.t%v", t_pid, i))
+		c.Add(p, 1)
+		c.Add(t_peak, -1)
+		cx = append(cx, c)
+	}
+	return cx
+}
@@ -0,0 +1,143 @@
+package cgc_optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewGridUnit(t *testing.T) {
+	pid, _ := uuid.NewUUID()
+	u := NewGridUnit(pid, 0.2, 0.05, 100, 50)
+
+	assert.Equal(t, []float64{0.2, -0.05}, u.CostCoefficients())
+	assert.Equal(t, [][2]float64{{0, 100}, {0, 50}}, u.Bounds())
+	assert.Equal(t, []string{"xi", "xe"}, u.ColumnNames())
+	assert.Equal(t, []int{0, 0}, u.Integrality())
+	assert.Equal(t, []int{0}, u.RealPositivePowerLoc())
+	assert.Equal(t, []int{1}, u.RealNegativePowerLoc())
+	assert.Empty(t, u.RealCapacityLoc())
+	assert.Empty(t, u.StoredEnergyLoc())
+}
+
+func TestGridUnitNetLoad(t *testing.T) {
+	pid, _ := uuid.NewUUID()
+	pvPID, _ := uuid.NewUUID()
+	grid := NewGridUnit(pid, 0.2, 0.05, 100, 4)
+	pv := NewBasicUnit(pvPID, 0, 0, 0, 0, 10, 0, 0, 0)
+
+	g := NewGroup(grid, pv)
+	err := g.NewSparseConstraint(NetLoadConstraint(&g, 5))
+	assert.Nil(t, err)
+	s := NewSeries(NewCluster(g))
+	err = s.SetUpperBoundProfile(s.RealPositivePowerPidLoc(pvPID), []float64{10})
+	assert.Nil(t, err)
+	err = s.SetCostProfile(s.RealNegativePowerPidLoc(pid), []float64{-0.1})
+	assert.Nil(t, err)
+
+	// pv serves the load and exports up to the export limit
+	res, err := NativeSolver{}.SolveLp(s, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDeltaSlice(t, []float64{0, 4}, res.Columns[:2], 1e-6)
+	assert.InDelta(t, -0.4, res.Objective, 1e-6)
+}
+
+func TestGridUnitEnableDirection(t *testing.T) {
+	pid, _ := uuid.NewUUID()
+	u := NewGridUnit(pid, 1, 2, 10, 10)
+
+	// export paid above the import price pays to import and export at once
+	g := NewGroup(u)
+	err := g.NewSparseConstraint(NetLoadConstraint(&g, 5))
+	assert.Nil(t, err)
+	res, err := NativeSolver{}.SolveLp(NewSeries(NewCluster(g)), SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDeltaSlice(t, []float64{10, 5}, res.Columns[:2], 1e-6)
+	assert.InDelta(t, 0, res.Objective, 1e-6)
+
+	err = u.EnableDirection()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"xi", "xe", "xd"}, u.ColumnNames())
+	assert.Equal(t, []int{0, 0, 1}, u.Integrality())
+	assert.Equal(t, []int{2}, u.DirectionLoc())
+	assert.Equal(t, [][]float64{
+		{math.Inf(-1), 1, 0, -10, 0},
+		{math.Inf(-1), 0, 1, 10, 10}}, u.Constraints())
+
+	g = NewGroup(u)
+	err = g.NewSparseConstraint(NetLoadConstraint(&g, 5))
+	assert.Nil(t, err)
+	res, err = NativeSolver{}.SolveMip(NewSeries(NewCluster(g)), SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDeltaSlice(t, []float64{5, 0}, res.Columns[:2], 1e-6)
+	assert.InDelta(t, 5, res.Objective, 1e-6)
+
+	v := NewGridUnit(pid, 1, 2, math.Inf(1), 10)
+	assert.Error(t, v.EnableDirection())
+}
+
+func TestGridDemandCharge(t *testing.T) {
+	inf := math.Inf(1)
+	pid, _ := uuid.NewUUID()
+	essPID, _ := uuid.NewUUID()
+	grid := NewGridUnit(pid, 1, 0, inf, 0)
+	ess := NewBasicUnit(essPID, 0, 0, 0, 0, 10, 10, 0, 20)
+
+	clx := make([]Sequencer, 0)
+	for _, nl := range []float64{2, 2, 10, 2} {
+		g := NewGroup(grid, ess)
+		err := g.NewSparseConstraint(NetLoadConstraint(&g, nl))
+		assert.Nil(t, err)
+		clx = append(clx, g)
+	}
+	s := NewSeries(clx...)
+	size := s.ColumnSize()
+
+	peak := s.NewUnitAuxiliaryColumn(pid, "peak", 10, 0, inf)
+	assert.Equal(t, size, peak)
+	assert.Equal(t, size+1, s.ColumnSize())
+	assert.Equal(t, pid.String()+".peak", s.ColumnNames()[peak])
+	assert.Len(t, s.Integrality(), size+1)
+	assert.Equal(t, 10.0, s.CostCoefficients()[peak])
+	assert.Equal(t, [2]float64{0, inf}, s.Bounds()[peak])
+
+	rx := GridDemandChargeConstraints(&s, pid, peak)
+	assert.Len(t, rx, 4)
+	assert.Equal(t, pid.String()+".demand.t2", rx[2].Name)
+	err := s.NewSparseConstraint(rx...)
+	assert.Nil(t, err)
+	err = s.NewSparseConstraint(BatteryInitialEnergyConstraint(&s, essPID, 0))
+	assert.Nil(t, err)
+	err = s.NewSparseConstraint(BatteryEnergyConstraint(&s, essPID, 1)...)
+	assert.Nil(t, err)
+	fc, err := BatteryFinalEnergyConstraint(&s, essPID, 1, 1, 1, 0)
+	assert.Nil(t, err)
+	err = s.NewSparseConstraint(fc)
+	assert.Nil(t, err)
+
+	// charged at t0 and t1, the battery shaves the load at t2 down to a peak of 4.67
+	res, err := NativeSolver{}.SolveLp(s, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 14.0/3, res.Columns[peak], 1e-6)
+	assert.InDelta(t, 16+10*14.0/3, res.Objective, 1e-6)
+
+	// the demand charge is part of the cost of the grid unit
+	gt := CostTerm("grid", &s, pid)
+	assert.Contains(t, gt.Index, peak)
+	assert.InDelta(t, res.Objective, gt.Evaluate(res.Columns), 1e-6)
+	assert.Equal(t, []int{peak}, s.AuxiliaryPidLoc(pid))
+	assert.Empty(t, s.AuxiliaryPidLoc(essPID))
+
+	srx, err := s.Decode(res.Columns)
+	assert.Nil(t, err)
+	assert.Len(t, srx, 4)
+
+	// columns are assigned by owner, not by name
+	named := s.NewAuxiliaryColumn(pid.String()+".other", 1, 0, inf)
+	owned := s.NewUnitAuxiliaryColumn(pid, "other", 1, 0, inf)
+	gt = CostTerm("grid", &s, pid)
+	assert.NotContains(t, gt.Index, named)
+	assert.Contains(t, gt.Index, owned)
+}
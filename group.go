@@ -1,8 +1,6 @@
 package cgc_optimize
 
 import (
-	"errors"
-	"fmt"
 	"math"
 
 	"github.com/google/uuid"
@@ -10,13 +8,15 @@ import (
 
 type Group struct {
 	units       []Unit
-	constraints [][]float64
+	constraints []SparseConstraint
 }
 
+var _ MipLinearProgram = Group{}
+
 func NewGroup(units ...Unit) Group {
 	ux := make([]Unit, 0)
 	ux = append(ux, units...)
-	cx := make([][]float64, 0)
+	cx := make([]SparseConstraint, 0)
 
 	return Group{ux, cx}
 }
@@ -40,13 +40,9 @@ func (g Group) ColumnSize() int {
 }
 
 func (g *Group) NewConstraint(t_c ...[]float64) error {
-	cx := make([][]float64, 0)
-	for _, c := range t_c {
-		if len(c) != g.ColumnSize()+2 {
-			err := fmt.Sprintf("constraint contains %v columns, expected: %v", len(c), g.ColumnSize()+2)
-			return errors.New(err)
-		}
-		cx = append(cx, c)
+	cx, err := validateDense(g.ColumnSize(), t_c)
+	if err != nil {
+		return err
 	}
 
 	// if no errors, append constraints to group
@@ -54,21 +50,27 @@ func (g *Group) NewConstraint(t_c ...[]float64) error {
 	return nil
 }
 
+func (g *Group) NewSparseConstraint(t_c ...SparseConstraint) error {
+	if err := validateSparse(g.ColumnSize(), t_c); err != nil {
+		return err
+	}
+
+	g.constraints = append(g.constraints, t_c...)
+	return nil
+}
+
 func (g Group) Constraints() [][]float64 {
-	s := g.ColumnSize()
-	gc := make([][]float64, 0)
+	return densify(g.ColumnSize(), g.SparseConstraints())
+}
+
+// SparseConstraints returns the constraints of each unit, shifted to the unit's columns,
+// followed by the group constraints.
+func (g Group) SparseConstraints() []SparseConstraint {
+	gc := make([]SparseConstraint, 0)
 
 	i := 0
 	for _, u := range g.units {
-		pre := make([]float64, i+1)
-		post := make([]float64, s-i-u.ColumnSize()+1)
-		for _, uc := range u.Constraints() {
-			pre[0] = lb(uc)            // shift lower bound to index 0
-			post[len(post)-1] = ub(uc) // shift upper bound at last index
-
-			c := append(append(pre, cons(uc)...), post...)
-			gc = append(gc, c)
-		}
+		gc = shiftConstraints(gc, u.SparseConstraints(), i, unitPrefix(u))
 		i += u.ColumnSize()
 	}
 
@@ -76,6 +78,25 @@ func (g Group) Constraints() [][]float64 {
 	return gc
 }
 
+// ColumnNames returns the name of each column, qualified by unit PID.
+func (g Group) ColumnNames() []string {
+	names := make([]string, 0)
+	for _, u := range g.units {
+		names = append(names, prefixNames(unitPrefix(u), u.ColumnNames())...)
+	}
+	return names
+}
+
+// ConstraintNames returns the name of each constraint row.
+func (g Group) ConstraintNames() []string {
+	return constraintNames(g.SparseConstraints())
+}
+
+// unitPrefix returns the qualifier of the names of unit u within a group
+func unitPrefix(u Unit) string {
+	return u.PID().String() + "."
+}
+
 func (g Group) Bounds() [][2]float64 {
 	b := make([][2]float64, 0)
 
@@ -85,6 +106,15 @@ func (g Group) Bounds() [][2]float64 {
 	return b
 }
 
+// Integrality returns the integer mask of the group columns.
+func (g Group) Integrality() []int {
+	mask := make([]int, 0)
+	for _, u := range g.units {
+		mask = append(mask, u.Integrality()...)
+	}
+	return mask
+}
+
 func (g Group) RealPositivePowerLoc() []int {
 	loc := make([]int, 0)
 	i := 0
@@ -175,32 +205,52 @@ func (g Group) StoredEnergyPidLoc(t_pid uuid.UUID) []int {
 	return loc
 }
 
+// RealCapacityPidLoc returns the real capacity columns of each unit with PID t_pid.
+func (g Group) RealCapacityPidLoc(t_pid uuid.UUID) []int {
+	return g.PidLoc(t_pid, realCapacityLoc)
+}
+
+// PidLoc returns the columns selected by t_loc of each unit with PID t_pid.
+func (g Group) PidLoc(t_pid uuid.UUID, t_loc func(Unit) []int) []int {
+	loc := make([]int, 0)
+	i := 0
+	for _, u := range g.units {
+		if u.PID() == t_pid {
+			for _, p := range t_loc(u) {
+				loc = append(loc, p+i)
+			}
+		}
+		i += u.ColumnSize()
+	}
+	return loc
+}
+
 // Constraint Generation
 
 // NetLoadConstraint returns a constraint of the form: Sum_i(Xp_i - Xn_i) == t_nl
-func NetLoadConstraint(g *Group, t_nl float64) []float64 {
-	c := make([]float64, g.ColumnSize())
+func NetLoadConstraint(g *Group, t_nl float64) SparseConstraint {
+	c := NewSparseConstraint(t_nl, t_nl).Named("net_load")
 
 	rpp := g.RealPositivePowerLoc()
 	rnp := g.RealNegativePowerLoc()
 	for _, i := range rpp {
-		c[i] = 1.0
+		c.Add(i, 1.0)
 	}
 	for _, i := range rnp {
-		c[i] = -1.0
+		c.Add(i, -1.0)
 	}
 
-	return boundConstraint(c, t_nl, t_nl)
+	return c
 }
 
 // GroupCapacityConstriant returns a constraint of the form: Sum_i(Xc_i) >= t_cap
-func GroupPositiveCapacityConstraint(g *Group, t_cap float64) []float64 {
-	c := make([]float64, g.ColumnSize())
+func GroupPositiveCapacityConstraint(g *Group, t_cap float64) SparseConstraint {
+	c := NewSparseConstraint(t_cap, math.Inf(1)).Named("positive_capacity")
 
 	pc := g.RealCapacityLoc()
 	for _, i := range pc {
-		c[i] = 1.0
+		c.Add(i, 1.0)
 	}
 
-	return boundConstraint(c, t_cap, math.Inf(1))
+	return c
 }
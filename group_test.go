@@ -16,7 +16,7 @@ func NewTestGroup() Group {
 
 func TestNewAssetVarsGroup(t *testing.T) {
 	ag0 := NewGroup()
-	assert.Equal(t, ag0.units, []BasicUnit{}, "empty group does not return empty units slice")
+	assert.Equal(t, ag0.units, []Unit{}, "empty group does not return empty units slice")
 
 	a1 := NewTestBasicUnit()
 	ag1 := NewGroup(a1)
@@ -138,7 +138,7 @@ func TestNetloadConstraint(t *testing.T) {
 	ag1 := NewTestGroup()
 	nl := 11.1
 	nlc := NetLoadConstraint(&ag1, nl)
-	assert.Equal(t, []float64{nl, 1, -1, 0, 0, 1, -1, 0, 0, nl}, nlc)
+	assert.Equal(t, []float64{nl, 1, -1, 0, 0, 1, -1, 0, 0, nl}, nlc.Dense(ag1.ColumnSize()))
 }
 
 func TestPositiveCapacityConstraint(t *testing.T) {
@@ -146,5 +146,32 @@ func TestPositiveCapacityConstraint(t *testing.T) {
 	pc := 22.2
 	pcc := GroupPositiveCapacityConstraint(&ag1, pc)
 	inf := math.Inf(1)
-	assert.Equal(t, []float64{pc, 0, 0, 1, 0, 0, 0, 1, 0, inf}, pcc)
+	assert.Equal(t, []float64{pc, 0, 0, 1, 0, 0, 0, 1, 0, inf}, pcc.Dense(ag1.ColumnSize()))
+}
+
+func TestGroupColumnNames(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	inf := math.Inf(1)
+	a1 := NewBasicUnit(pid1, 1, 2, 3, 4, inf, inf, inf, inf)
+	g := NewGroup(a1)
+
+	p := pid1.String()
+	assert.Equal(t, []string{p + ".xp", p + ".xn", p + ".xc", p + ".xe"}, g.ColumnNames())
+}
+
+func TestGroupConstraintNames(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	inf := math.Inf(1)
+	a1 := NewBasicUnit(pid1, 1, 2, 3, 4, inf, inf, inf, inf)
+	err := a1.NewSparseConstraint(BasicUnitCapacityConstraints(&a1)...)
+	assert.Nil(t, err)
+
+	g := NewGroup(a1)
+	err = g.NewSparseConstraint(NetLoadConstraint(&g, 1))
+	assert.Nil(t, err)
+	err = g.NewConstraint([]float64{0, 1, 0, 0, 0, 1})
+	assert.Nil(t, err)
+
+	p := pid1.String()
+	assert.Equal(t, []string{p + ".positive_capacity", p + ".negative_capacity", "net_load", "c3"}, g.ConstraintNames())
 }
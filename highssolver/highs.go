@@ -0,0 +1,100 @@
+// Package highssolver solves linear and mixed integer linear programs with HiGHS.
+// Importing it registers the solver under the name "highs".
+package highssolver
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	opt "github.com/ohowland/cgc_optimize"
+	"github.com/ohowland/highs"
+)
+
+func init() {
+	opt.RegisterSolver("highs", Solver{})
+}
+
+// Solver solves linear programs with HiGHS. The HiGHS Go bindings only load dense
+// constraint rows, which they pack into the sparse form passed to HiGHS, so each solve
+// holds a rows x columns matrix in memory; use the clp solver for large sparse programs.
+// The bindings do not report dual values, so Result.Duals is nil. TimeLimit is passed to
+// HiGHS and WarmStart is ignored. The HiGHS version the bindings target has no relative
+// gap option, so SolveMip returns an error when MipGap is set.
+type Solver struct{}
+
+func (Solver) SolveLp(w opt.LinearProgram, t_opts opt.SolverOptions) (opt.Result, error) {
+	return solve(w, []int{}, t_opts)
+}
+
+func (Solver) SolveMip(w opt.MipLinearProgram, t_opts opt.SolverOptions) (opt.Result, error) {
+	if t_opts.MipGap > 0 {
+		return opt.Result{Status: opt.StatusError}, errors.New("highs: mip gap option is not supported")
+	}
+	return solve(w, w.Integrality(), t_opts)
+}
+
+func solve(w opt.LinearProgram, t_intg []int, t_opts opt.SolverOptions) (res opt.Result, err error) {
+	if len(opt.SparseConstraintsOf(w)) == 0 {
+		return opt.Result{Status: opt.StatusError}, errors.New("highs: linear program has no constraints")
+	}
+
+	// the HiGHS bindings panic on malformed input, recover and report it as an error.
+	defer func() {
+		if r := recover(); r != nil {
+			res = opt.Result{Status: opt.StatusError}
+			err = fmt.Errorf("highs: %v", r)
+		}
+	}()
+
+	s, err := highs.New(
+		w.CostCoefficients(),
+		w.Bounds(),
+		w.Constraints(),
+		t_intg)
+
+	if err != nil {
+		return opt.Result{Status: opt.StatusError}, err
+	}
+
+	// the bindings only expose string and bool option setters, HiGHS parses numeric
+	// options from their string form.
+	if t_opts.TimeLimit > 0 {
+		s.SetStringOptionValue("time_limit", strconv.FormatFloat(t_opts.TimeLimit.Seconds(), 'g', -1, 64))
+	}
+
+	s.SetObjectiveSense(highs.Minimize)
+	_, runErr := s.RunSolver()
+
+	// the bindings report every non-optimal model status as an error, so the status is
+	// checked first to tell infeasible and unbounded models from solver failures.
+	status := highsStatus(s.GetModelStatus())
+	if status != opt.StatusOptimal {
+		return opt.Result{Status: status}, opt.SolveError{Status: status}
+	}
+	if runErr != nil {
+		return opt.Result{Status: opt.StatusError}, fmt.Errorf("highs: %v", runErr)
+	}
+
+	return opt.NewResult(w, s.PrimalColumnSolution(), nil), nil
+}
+
+// highsStatus maps a HiGHS model status to a solve status.
+func highsStatus(t_s highs.ModelStatus) opt.Status {
+	switch t_s {
+	case highs.ModelOptimal:
+		return opt.StatusOptimal
+	case highs.ModelInfeasible:
+		return opt.StatusInfeasible
+	case highs.ModelUnbounded:
+		return opt.StatusUnbounded
+	case highs.ModelUnboundedOrInfeasible:
+		return opt.StatusInfeasibleOrUnbounded
+	case highs.ModelObjectiveBound, highs.ModelObjectiveTarget, highs.ModelTimeLimit, highs.ModelIterationLimit:
+		return opt.StatusLimit
+	case highs.ModelNotset, highs.ModelUnknown:
+		return opt.StatusUnknown
+	default:
+		return opt.StatusError
+	}
+}
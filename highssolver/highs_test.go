@@ -0,0 +1,40 @@
+package highssolver
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	opt "github.com/ohowland/cgc_optimize"
+	"github.com/ohowland/cgc_optimize/solvertest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighsSolver(t *testing.T) {
+	solvertest.Run(t, Solver{})
+	solvertest.RunMip(t, Solver{})
+}
+
+func TestHighsRegistered(t *testing.T) {
+	s, err := opt.NewSolver("highs")
+	assert.Nil(t, err)
+	assert.Equal(t, Solver{}, s)
+}
+
+func TestHighsNoConstraints(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	a1 := opt.NewBasicUnit(pid1, 1.0, 2.0, 0.01, 0, 5, 5, 5, 5)
+	ag1 := opt.NewGroup(a1)
+
+	_, err := Solver{}.SolveLp(ag1, opt.SolverOptions{})
+	assert.Error(t, err)
+}
+
+func TestHighsMipGap(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	a1 := opt.NewBasicUnit(pid1, 1.0, 2.0, 0.01, 0, 5, 5, 5, 5)
+	ag1 := opt.NewGroup(a1)
+
+	res, err := Solver{}.SolveMip(ag1, opt.SolverOptions{MipGap: 0.01})
+	assert.Error(t, err)
+	assert.Equal(t, opt.StatusError, res.Status)
+}
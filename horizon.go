@@ -0,0 +1,163 @@
+package cgc_optimize
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Forecast is a series of values at a fixed resolution, beginning at Start.
+type Forecast struct {
+	Start  time.Time
+	Step   time.Duration
+	Values []float64
+}
+
+// Window returns the t_n values of the forecast beginning at t_start. The forecast must
+// have a resolution of t_step, and cover the window.
+func (f Forecast) Window(t_start time.Time, t_step time.Duration, t_n int) ([]float64, error) {
+	if f.Step != t_step {
+		err := fmt.Sprintf("forecast resolution %v does not match time step %v", f.Step, t_step)
+		return nil, errors.New(err)
+	}
+
+	offset := t_start.Sub(f.Start)
+	if offset < 0 || offset%t_step != 0 {
+		err := fmt.Sprintf("forecast beginning %v is not aligned with %v", f.Start, t_start)
+		return nil, errors.New(err)
+	}
+
+	k := int(offset / t_step)
+	if k+t_n > len(f.Values) {
+		err := fmt.Sprintf("forecast ends before %v", t_start.Add(time.Duration(t_n)*t_step))
+		return nil, errors.New(err)
+	}
+
+	vx := make([]float64, t_n)
+	copy(vx, f.Values[k:k+t_n])
+	return vx, nil
+}
+
+// Horizon describes the dispatch of a template group over a window of time steps.
+//
+// Template: Units and constraints of the group at each time step
+// Start: Beginning of the first time step
+// Step: Length of each time step
+// Steps: Number of time steps
+// NetLoad: Net load to be served by the group
+// PV: Available power of each renewable unit, or real positive power limit of any other
+// unit, keyed by PID
+// Tariff: Cost of real positive power of each unit, keyed by PID
+// Storage: Stored energy of each unit, keyed by PID
+type Horizon struct {
+	Template Group
+	Start    time.Time
+	Step     time.Duration
+	Steps    int
+	NetLoad  Forecast
+	PV       map[uuid.UUID]Forecast
+	Tariff   map[uuid.UUID]Forecast
+	Storage  map[uuid.UUID]SiteStorage
+}
+
+// Time returns the beginning of the t_k-th time step.
+func (h Horizon) Time(t_k int) time.Time {
+	return h.Start.Add(time.Duration(t_k) * h.Step)
+}
+
+// NewHorizonSeries returns the Series described by t_h. Each time step is a copy of the
+// template group balancing the net load forecast. Forecasts are checked against the
+// horizon resolution, which also sets the time step of the stored energy constraints.
+func NewHorizonSeries(t_h Horizon) (Series, error) {
+	if t_h.Steps < 1 {
+		err := fmt.Sprintf("horizon is %v steps, expected at least 1 time step", t_h.Steps)
+		return Series{}, errors.New(err)
+	}
+	if t_h.Step <= 0 {
+		err := fmt.Sprintf("horizon time step is %v, expected a positive duration", t_h.Step)
+		return Series{}, errors.New(err)
+	}
+
+	nl, err := t_h.NetLoad.Window(t_h.Start, t_h.Step, t_h.Steps)
+	if err != nil {
+		return Series{}, errors.New("net load " + err.Error())
+	}
+
+	sequence := make([]Sequencer, 0)
+	for t := 0; t < t_h.Steps; t++ {
+		g := NewGroup(t_h.Template.units...)
+		g.constraints = append(g.constraints, t_h.Template.constraints...)
+		if err := g.NewSparseConstraint(NetLoadConstraint(&g, nl[t])); err != nil {
+			return Series{}, err
+		}
+		sequence = append(sequence, g)
+	}
+	se := NewSeries(sequence...)
+
+	// units are visited in template order, so rows are built in a stable order
+	found := 0
+	visited := make(map[uuid.UUID]bool)
+	for _, u := range t_h.Template.units {
+		pid := u.PID()
+		if visited[pid] {
+			continue
+		}
+		visited[pid] = true
+
+		if f, ok := t_h.PV[pid]; ok {
+			loc, set := se.RealPositivePowerPidLoc(pid), se.SetUpperBoundProfile
+			if _, ok := u.(CurtailmentLoc); ok {
+				loc, set = se.AvailablePidLoc(pid), se.SetFixedProfile
+			}
+			if err := t_h.applyProfile(pid, loc, f, set); err != nil {
+				return Series{}, err
+			}
+			found++
+		}
+		if f, ok := t_h.Tariff[pid]; ok {
+			if err := t_h.applyProfile(pid, se.RealPositivePowerPidLoc(pid), f, se.SetCostProfile); err != nil {
+				return Series{}, err
+			}
+			found++
+		}
+
+		ss, ok := t_h.Storage[pid]
+		if !ok {
+			if len(depthEnergyLoc(u)) > 0 {
+				err := fmt.Sprintf("storage unit %v has a depth cost but no storage", pid)
+				return Series{}, errors.New(err)
+			}
+			continue
+		}
+		found++
+		if len(se.StoredEnergyPidLoc(pid)) != t_h.Steps {
+			err := fmt.Sprintf("storage unit %v does not store energy", pid)
+			return Series{}, errors.New(err)
+		}
+		if err := ss.validate(pid); err != nil {
+			return Series{}, err
+		}
+		if err := ss.apply(&se, pid, t_h.Step.Hours()); err != nil {
+			return Series{}, err
+		}
+	}
+
+	if found != len(t_h.PV)+len(t_h.Tariff)+len(t_h.Storage) {
+		return Series{}, errors.New("horizon forecast or storage references a unit not in the template")
+	}
+
+	return se, nil
+}
+
+// applyProfile sets the window of forecast t_f on the columns t_loc of unit t_pid with
+// t_set.
+func (h Horizon) applyProfile(t_pid uuid.UUID, t_loc []int, t_f Forecast, t_set func([]int, []float64) error) error {
+	vx, err := t_f.Window(h.Start, h.Step, h.Steps)
+	if err != nil {
+		err := fmt.Sprintf("unit %v %v", t_pid, err)
+		return errors.New(err)
+	}
+	return t_set(t_loc, vx)
+}
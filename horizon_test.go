@@ -0,0 +1,103 @@
+package cgc_optimize
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForecastWindow(t *testing.T) {
+	start := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	f := Forecast{start, 15 * time.Minute, []float64{1, 2, 3, 4, 5}}
+
+	vx, err := f.Window(start.Add(30*time.Minute), 15*time.Minute, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{3, 4, 5}, vx)
+
+	_, err = f.Window(start, time.Hour, 1)
+	assert.Error(t, err, "resolution mismatch accepted")
+	_, err = f.Window(start.Add(5*time.Minute), 15*time.Minute, 1)
+	assert.Error(t, err, "misaligned start accepted")
+	_, err = f.Window(start.Add(-15*time.Minute), 15*time.Minute, 1)
+	assert.Error(t, err, "start before forecast accepted")
+	_, err = f.Window(start.Add(time.Hour), 15*time.Minute, 2)
+	assert.Error(t, err, "window past forecast accepted")
+}
+
+func TestNewHorizonSeries(t *testing.T) {
+	inf := math.Inf(1)
+	gridPID, _ := uuid.NewUUID()
+	pvPID, _ := uuid.NewUUID()
+	essPID, _ := uuid.NewUUID()
+	grid := NewBasicUnit(gridPID, 0, 0, 0, 0, inf, 0, 0, 0)
+	pv := NewBasicUnit(pvPID, 0, 0, 0, 0, inf, 0, 0, 0)
+	ess := NewBasicUnit(essPID, 0, 0, 0, 0, 10, 10, 0, 20)
+	tmpl := NewGroup(grid, pv, ess)
+
+	start := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	step := 30 * time.Minute
+	e0 := 0.0
+	h := Horizon{
+		Template: tmpl,
+		Start:    start,
+		Step:     step,
+		Steps:    3,
+		NetLoad:  Forecast{start, step, []float64{5, 5, 5}},
+		PV:       map[uuid.UUID]Forecast{pvPID: {start, step, []float64{15, 0, 0}}},
+		Tariff:   map[uuid.UUID]Forecast{gridPID: {start, step, []float64{1, 4, 4}}},
+		Storage:  map[uuid.UUID]SiteStorage{essPID: {InitialEnergy: &e0}},
+	}
+
+	se, err := NewHorizonSeries(h)
+	assert.Nil(t, err)
+	assert.Equal(t, start.Add(time.Hour), h.Time(2))
+	assert.Len(t, tmpl.SparseConstraints(), 0, "template modified")
+
+	res, err := NativeSolver{}.SolveLp(se, SolverOptions{})
+	assert.Nil(t, err)
+	srx, err := se.Decode(res.Columns)
+	assert.Nil(t, err)
+
+	// pv surplus at t0 charges the 2.5 kWh served at t1 over half an hour
+	ux := UnitSeries(srx, essPID)
+	assert.GreaterOrEqual(t, ux[0].RealNegativePower, 5-1e-6)
+	assert.InDelta(t, 5, ux[1].RealPositivePower, 1e-6)
+	assert.InDelta(t, 0, UnitSeries(srx, gridPID)[1].RealPositivePower, 1e-6)
+}
+
+func TestNewHorizonSeriesErrors(t *testing.T) {
+	pid, _ := uuid.NewUUID()
+	other, _ := uuid.NewUUID()
+	ppid, _ := uuid.NewUUID()
+	pu := NewPiecewiseUnit(ppid, []CriticalPoint{{0, 0}, {10, 1}})
+	tmpl := NewGroup(NewBasicUnit(pid, 1, 0, 0, 0, 10, 0, 0, 0), pu)
+
+	start := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	h := Horizon{Template: tmpl, Start: start, Step: time.Hour, Steps: 2,
+		NetLoad: Forecast{start, time.Hour, []float64{1, 2}}}
+	_, err := NewHorizonSeries(h)
+	assert.Nil(t, err)
+
+	bad := h
+	bad.NetLoad = Forecast{start, 15 * time.Minute, []float64{1, 2, 3, 4, 5, 6, 7, 8}}
+	_, err = NewHorizonSeries(bad)
+	assert.Error(t, err, "net load resolution mismatch accepted")
+
+	bad = h
+	bad.Tariff = map[uuid.UUID]Forecast{other: {start, time.Hour, []float64{1, 2}}}
+	_, err = NewHorizonSeries(bad)
+	assert.Error(t, err, "tariff of unknown unit accepted")
+
+	bad = h
+	bad.Storage = map[uuid.UUID]SiteStorage{ppid: {}}
+	_, err = NewHorizonSeries(bad)
+	assert.Error(t, err, "storage of unit without stored energy accepted")
+
+	bad = h
+	bad.Steps = 0
+	_, err = NewHorizonSeries(bad)
+	assert.Error(t, err)
+}
@@ -0,0 +1,169 @@
+package cgc_optimize
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// SheddingLoc is implemented by units whose demand may be shed.
+type SheddingLoc interface {
+	DemandLoc() []int
+	ShedLoc() []int
+}
+
+// LoadUnit is a controllable load. Served load is real negative power, so the unit is
+// balanced by the NetLoadConstraint of its group; demand not served is shed.
+type LoadUnit struct {
+	pid          uuid.UUID
+	coefficients []float64
+	bounds       [][2]float64
+	constraints  []SparseConstraint
+}
+
+var _ Unit = LoadUnit{}
+var _ SheddingLoc = LoadUnit{}
+
+// NewLoadUnit returns a configured load unit struct. Demand is a column bounded by XdLb
+// and XdUb: a fixed load has equal bounds, or a profile fixed for each time step of a
+// series with SetFixedProfile at DemandPidLoc. A deferrable load has a lower bound of
+// zero, with its energy scheduled by LoadDeferrableConstraints.
+//
+// Cs: Value of lost load, the penalty cost for shed demand
+//
+// XdLb: Lower bound for demand
+// XdUb: Upper bound for demand, the limit of served load
+func NewLoadUnit(pid uuid.UUID, Cs float64, XdLb float64, XdUb float64) LoadUnit {
+	coefficients := []float64{0, Cs, 0}
+	bounds := [][2]float64{{0, XdUb}, {0, math.Inf(1)}, {XdLb, XdUb}}
+
+	// xn + xs = xd
+	demand := NewSparseConstraint(0, 0).Named("demand")
+	demand.Add(0, 1)
+	demand.Add(1, 1)
+	demand.Add(2, -1)
+
+	return LoadUnit{pid, coefficients, bounds, []SparseConstraint{demand}}
+}
+
+func (u LoadUnit) PID() uuid.UUID {
+	return u.pid
+}
+
+func (u LoadUnit) CostCoefficients() []float64 {
+	return u.coefficients
+}
+
+func (u LoadUnit) ColumnSize() int {
+	return len(u.coefficients)
+}
+
+// ColumnNames returns the name of each column: xn, xs and xd
+func (u LoadUnit) ColumnNames() []string {
+	return []string{"xn", "xs", "xd"}
+}
+
+func (u LoadUnit) Integrality() []int {
+	return make([]int, u.ColumnSize())
+}
+
+func (u *LoadUnit) NewConstraint(t_c ...[]float64) error {
+	cx, err := validateDense(u.ColumnSize(), t_c)
+	if err != nil {
+		return err
+	}
+
+	// if no errors: add constraints to unit
+	u.constraints = append(u.constraints, cx...)
+	return nil
+}
+
+func (u *LoadUnit) NewSparseConstraint(t_c ...SparseConstraint) error {
+	if err := validateSparse(u.ColumnSize(), t_c); err != nil {
+		return err
+	}
+
+	u.constraints = append(u.constraints, t_c...)
+	return nil
+}
+
+func (u LoadUnit) Constraints() [][]float64 {
+	return densify(u.ColumnSize(), u.constraints)
+}
+
+func (u LoadUnit) SparseConstraints() []SparseConstraint {
+	return u.constraints
+}
+
+func (u LoadUnit) Bounds() [][2]float64 {
+	return u.bounds
+}
+
+func (u LoadUnit) RealPositivePowerLoc() []int {
+	return []int{}
+}
+
+func (u LoadUnit) RealNegativePowerLoc() []int {
+	return []int{0}
+}
+
+func (u LoadUnit) RealCapacityLoc() []int {
+	return []int{}
+}
+
+func (u LoadUnit) StoredEnergyLoc() []int {
+	return []int{}
+}
+
+func (u LoadUnit) ShedLoc() []int {
+	return []int{1}
+}
+
+func (u LoadUnit) DemandLoc() []int {
+	return []int{2}
+}
+
+// demandLoc and shedLoc select the shedding columns of u, empty if u cannot be shed.
+func demandLoc(u Unit) []int {
+	if su, ok := u.(SheddingLoc); ok {
+		return su.DemandLoc()
+	}
+	return []int{}
+}
+
+func shedLoc(u Unit) []int {
+	if su, ok := u.(SheddingLoc); ok {
+		return su.ShedLoc()
+	}
+	return []int{}
+}
+
+// Constraints
+
+// LoadDeferrableConstraints returns constraints of the form: Sum_i(Xd_i * tstep) = E for
+// the time steps t_first to t_last of the series, and Xd_i = 0 at every other time step.
+// The deferrable load of unit t_pid requires t_e energy within the window; energy not
+// served is shed. It returns an error if the window is empty or outside of the series.
+func LoadDeferrableConstraints(t_se *Series, t_pid uuid.UUID, t_first int, t_last int, t_e float64, t_tstep float64) ([]SparseConstraint, error) {
+	dLoc := t_se.DemandPidLoc(t_pid)
+	if t_first < 0 || t_last >= len(dLoc) || t_first > t_last {
+		err := fmt.Sprintf("unit %v deferrable window [%v, %v] outside of the %v demand steps", t_pid, t_first, t_last, len(dLoc))
+		return []SparseConstraint{}, errors.New(err)
+	}
+
+	energy := NewSparseConstraint(t_e, t_e).Named(fmt.Sprintf("%v.deferrable_energy", t_pid))
+	cx := make([]SparseConstraint, 0)
+	for i, d := range dLoc {
+		if i >= t_first && i <= t_last {
+			energy.Add(d, t_tstep)
+			continue
+		}
+
+		c := NewSparseConstraint(0, 0).Named(fmt.Sprintf("%v.deferrable_idle.t%v", t_pid, i))
+		c.Add(d, 1)
+		cx = append(cx, c)
+	}
+	return append([]SparseConstraint{energy}, cx...), nil
+}
@@ -0,0 +1,113 @@
+package cgc_optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLoadUnit(t *testing.T) {
+	pid, _ := uuid.NewUUID()
+	u := NewLoadUnit(pid, 100, 5, 5)
+
+	assert.Equal(t, []float64{0, 100, 0}, u.CostCoefficients())
+	assert.Equal(t, [][2]float64{{0, 5}, {0, math.Inf(1)}, {5, 5}}, u.Bounds())
+	assert.Equal(t, []string{"xn", "xs", "xd"}, u.ColumnNames())
+	assert.Equal(t, [][]float64{{0, 1, 1, -1, 0}}, u.Constraints())
+	assert.Equal(t, []int{0}, u.RealNegativePowerLoc())
+	assert.Empty(t, u.RealPositivePowerLoc())
+	assert.Equal(t, []int{1}, shedLoc(u))
+	assert.Equal(t, []int{2}, demandLoc(u))
+	assert.Empty(t, shedLoc(NewTestBasicUnit()))
+}
+
+func TestLoadUnitShed(t *testing.T) {
+	pid, _ := uuid.NewUUID()
+	genPID, _ := uuid.NewUUID()
+	load := NewLoadUnit(pid, 10, 0, 20)
+	gen := NewBasicUnit(genPID, 1, 0, 0, 0, 8, 0, 0, 0)
+
+	clx := make([]Sequencer, 0)
+	for i := 0; i < 2; i++ {
+		g := NewGroup(load, gen)
+		err := g.NewSparseConstraint(NetLoadConstraint(&g, 0))
+		assert.Nil(t, err)
+		clx = append(clx, g)
+	}
+	s := NewSeries(clx...)
+	err := s.SetFixedProfile(s.DemandPidLoc(pid), []float64{5, 12})
+	assert.Nil(t, err)
+
+	res, err := NativeSolver{}.SolveLp(s, SolverOptions{})
+	assert.Nil(t, err)
+	srx, err := s.Decode(res.Columns)
+	assert.Nil(t, err)
+
+	// demand over the generator limit is shed at the value of lost load
+	ux := UnitSeries(srx, pid)
+	assert.InDelta(t, 5, ux[0].RealNegativePower, 1e-6)
+	assert.InDelta(t, 0, ux[0].Shed, 1e-6)
+	assert.InDelta(t, 8, ux[1].RealNegativePower, 1e-6)
+	assert.InDelta(t, 4, ux[1].Shed, 1e-6)
+	assert.InDelta(t, 13+10*4, res.Objective, 1e-6)
+}
+
+func TestLoadDeferrableConstraints(t *testing.T) {
+	pid, _ := uuid.NewUUID()
+	gridPID, _ := uuid.NewUUID()
+	ev := NewLoadUnit(pid, 50, 0, 7)
+	grid := NewGridUnit(gridPID, 1, 0, math.Inf(1), 0)
+
+	g := NewGroup(ev, grid)
+	err := g.NewSparseConstraint(NetLoadConstraint(&g, 0))
+	assert.Nil(t, err)
+	s := NewSeriesFromTemplate(g, 4)
+	err = s.SetCostProfile(s.RealPositivePowerPidLoc(gridPID), []float64{1, 3, 2, 1})
+	assert.Nil(t, err)
+
+	rx, err := LoadDeferrableConstraints(&s, pid, 1, 3, 10, 0.5)
+	assert.Nil(t, err)
+	assert.Len(t, rx, 2)
+	assert.Equal(t, pid.String()+".deferrable_energy", rx[0].Name)
+	assert.Equal(t, pid.String()+".deferrable_idle.t0", rx[1].Name)
+	for _, w := range [][2]int{{2, 4}, {-1, 2}, {3, 1}} {
+		_, err = LoadDeferrableConstraints(&s, pid, w[0], w[1], 10, 0.5)
+		assert.Error(t, err, "window accepted: %v", w)
+	}
+	err = s.NewSparseConstraint(rx...)
+	assert.Nil(t, err)
+
+	res, err := NativeSolver{}.SolveLp(s, SolverOptions{})
+	assert.Nil(t, err)
+	srx, err := s.Decode(res.Columns)
+	assert.Nil(t, err)
+
+	// 10 kWh over half hour steps at up to 7 kW, served at the cheapest steps in the window
+	ux := UnitSeries(srx, pid)
+	served := []float64{ux[0].RealNegativePower, ux[1].RealNegativePower, ux[2].RealNegativePower, ux[3].RealNegativePower}
+	assert.InDeltaSlice(t, []float64{0, 6, 7, 7}, served, 1e-6)
+	assert.InDelta(t, 6*3+7*2+7*1, res.Objective, 1e-6)
+}
+
+func TestLoadUnitRamp(t *testing.T) {
+	pid, _ := uuid.NewUUID()
+	load := NewLoadUnit(pid, 10, 0, 20)
+	g := NewGroup(load)
+	s := NewSeries(g, g, g)
+
+	// a load has no real positive power, so its served load is ramped
+	rc := RampRateConstraints(&s, pid, 2, 1, 1)
+	assert.Len(t, rc, 2)
+	assert.Equal(t, []float64{-1, 0, 0, 0, 1, 0, 0, -1, 0, 0, 2}, rc[1].Dense(s.ColumnSize()))
+
+	ic := InitialRampRateConstraint(&s, pid, 2, 1, 1, -4)
+	assert.Equal(t, []float64{-5, -1, 0, 0, 0, 0, 0, 0, 0, 0, -2}, ic.Dense(s.ColumnSize()))
+
+	other, _ := uuid.NewUUID()
+	assert.Empty(t, RampRateConstraints(&s, other, 2, 1, 1))
+	ic = InitialRampRateConstraint(&s, other, 2, 1, 1, 0)
+	assert.Empty(t, ic.Index)
+	assert.True(t, math.IsInf(ic.Ub, 1))
+}
@@ -0,0 +1,593 @@
+package cgc_optimize
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strings"
+)
+
+// lpTermsPerLine is the number of expression terms written to each line of an LP file.
+const lpTermsPerLine = 8
+
+// lpSymbols are the characters other than letters and digits allowed in LP file names.
+const lpSymbols = "!\"#$%&()/,.;?@_`'{}|~"
+
+// WriteLP writes w to t_w in CPLEX LP format. Names follow WriteMPS, with characters the
+// format does not allow (such as the hyphens of a PID) replaced by underscores and names
+// that start with a digit or period prefixed. Every column is written to the objective
+// so the column order is preserved when the file is read back. Rows bounded on both sides
+// are written as ranged constraints: lb <= expression <= ub.
+func WriteLP(t_w io.Writer, w LinearProgram) error {
+	m := NewModel(w)
+	cols, rows := fileNames(w, lpValid, "0123456789.")
+	n := len(m.Cost)
+	if len(m.ColBounds) != n {
+		err := fmt.Sprintf("program contains %v bounds, expected: %v", len(m.ColBounds), n)
+		return errors.New(err)
+	}
+	if err := validateSparse(n, m.Rows); err != nil {
+		return err
+	}
+	if n == 0 && len(m.Rows) > 0 {
+		return errors.New("program contains constraints but no columns")
+	}
+
+	b := bufio.NewWriter(t_w)
+	if mw, ok := w.(Model); ok && mw.Name != "" {
+		fmt.Fprintf(b, "\\ %v\n", mw.Name)
+	}
+
+	fmt.Fprintln(b, "Minimize")
+	fmt.Fprint(b, " obj:")
+	for j, c := range m.Cost {
+		writeLPTerm(b, j, c, cols[j])
+	}
+	fmt.Fprintln(b)
+
+	fmt.Fprintln(b, "Subject To")
+	for i, c := range m.Rows {
+		fmt.Fprintf(b, " %v:", rows[i])
+		ranged := c.Lb != c.Ub && !math.IsInf(c.Lb, -1) && !math.IsInf(c.Ub, 1)
+		if ranged {
+			fmt.Fprintf(b, " %v <=", formatNumber(c.Lb))
+		}
+		if len(c.Index) == 0 {
+			fmt.Fprintf(b, " 0 %v", cols[0])
+		}
+		for k, j := range c.Index {
+			writeLPTerm(b, k, c.Value[k], cols[j])
+		}
+		switch {
+		case c.Lb == c.Ub:
+			fmt.Fprintf(b, " = %v\n", formatNumber(c.Lb))
+		case !math.IsInf(c.Ub, 1):
+			fmt.Fprintf(b, " <= %v\n", formatNumber(c.Ub))
+		default:
+			fmt.Fprintf(b, " >= %v\n", formatNumber(c.Lb))
+		}
+	}
+
+	fmt.Fprintln(b, "Bounds")
+	for j, bnd := range m.ColBounds {
+		lo, up := bnd[0], bnd[1]
+		switch {
+		case lo == up:
+			fmt.Fprintf(b, " %v = %v\n", cols[j], formatNumber(lo))
+		case math.IsInf(lo, -1) && math.IsInf(up, 1):
+			fmt.Fprintf(b, " %v free\n", cols[j])
+		case lo != 0 || !math.IsInf(up, 1):
+			fmt.Fprintf(b, " %v <= %v <= %v\n", formatNumber(lo), cols[j], formatNumber(up))
+		}
+	}
+
+	generals := make([]string, 0)
+	for j, v := range m.Integer {
+		if v != 0 {
+			generals = append(generals, cols[j])
+		}
+	}
+	if len(generals) > 0 {
+		fmt.Fprintln(b, "Generals")
+		for k := 0; k < len(generals); k += lpTermsPerLine {
+			end := k + lpTermsPerLine
+			if end > len(generals) {
+				end = len(generals)
+			}
+			fmt.Fprintf(b, " %v\n", strings.Join(generals[k:end], " "))
+		}
+	}
+
+	fmt.Fprintln(b, "End")
+	return b.Flush()
+}
+
+// writeLPTerm writes the k-th term of an expression, wrapping long expressions.
+func writeLPTerm(t_b *bufio.Writer, t_k int, t_v float64, t_name string) {
+	if t_k > 0 && t_k%lpTermsPerLine == 0 {
+		fmt.Fprint(t_b, "\n  ")
+	}
+	sign := "+"
+	if t_v < 0 || (t_v == 0 && math.Signbit(t_v)) {
+		sign, t_v = "-", -t_v
+	}
+	fmt.Fprintf(t_b, " %v %v %v", sign, formatNumber(t_v), t_name)
+}
+
+func lpValid(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') ||
+		strings.ContainsRune(lpSymbols, r)
+}
+
+// ReadLP reads a linear program in CPLEX LP format, including ranged constraints and the
+// Bounds, Generals and Binaries sections. A maximized objective is negated, as a Model is
+// always minimized, and objective constants are dropped. Columns are numbered in the
+// order they first appear.
+func ReadLP(t_r io.Reader) (Model, error) {
+	text, err := ioutil.ReadAll(t_r)
+	if err != nil {
+		return Model{}, err
+	}
+	tokens, err := lexLP(string(text))
+	if err != nil {
+		return Model{}, err
+	}
+
+	p := lpParser{tokens: tokens, colIndex: make(map[string]int)}
+	if err := p.parse(); err != nil {
+		return Model{}, err
+	}
+	return p.m, nil
+}
+
+// lpToken is a lexical token of an LP file.
+//
+// kind: 'n' name, 'v' number, 'o' relational operator, 's' sign, ':' label separator
+type lpToken struct {
+	kind byte
+	text string
+	val  float64
+	line int
+}
+
+// lexLP splits an LP file into tokens, dropping comments.
+func lexLP(t_text string) ([]lpToken, error) {
+	tokens := make([]lpToken, 0)
+	line := 1
+	for i := 0; i < len(t_text); {
+		c := t_text[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '\\':
+			for i < len(t_text) && t_text[i] != '\n' {
+				i++
+			}
+		case c == ':':
+			tokens = append(tokens, lpToken{kind: ':', text: ":", line: line})
+			i++
+		case c == '+' || c == '-':
+			tokens = append(tokens, lpToken{kind: 's', text: string(c), line: line})
+			i++
+		case c == '<' || c == '>' || c == '=':
+			j := i
+			for j < len(t_text) && j < i+2 && strings.ContainsRune("<>=", rune(t_text[j])) {
+				j++
+			}
+			op := "="
+			if strings.ContainsRune(t_text[i:j], '<') {
+				op = "<="
+			} else if strings.ContainsRune(t_text[i:j], '>') {
+				op = ">="
+			}
+			tokens = append(tokens, lpToken{kind: 'o', text: op, line: line})
+			i = j
+		case (c >= '0' && c <= '9') || (c == '.' && i+1 < len(t_text) && t_text[i+1] >= '0' && t_text[i+1] <= '9'):
+			j := i
+			for j < len(t_text) && ((t_text[j] >= '0' && t_text[j] <= '9') || t_text[j] == '.') {
+				j++
+			}
+			if j < len(t_text) && (t_text[j] == 'e' || t_text[j] == 'E') {
+				k := j + 1
+				if k < len(t_text) && (t_text[k] == '+' || t_text[k] == '-') {
+					k++
+				}
+				if k < len(t_text) && t_text[k] >= '0' && t_text[k] <= '9' {
+					for k < len(t_text) && t_text[k] >= '0' && t_text[k] <= '9' {
+						k++
+					}
+					j = k
+				}
+			}
+			v, err := parseNumber(t_text[i:j])
+			if err != nil {
+				err := fmt.Sprintf("lp line %v: %v", line, err)
+				return []lpToken{}, errors.New(err)
+			}
+			tokens = append(tokens, lpToken{kind: 'v', text: t_text[i:j], val: v, line: line})
+			i = j
+		case lpValid(rune(c)):
+			j := i
+			for j < len(t_text) && lpValid(rune(t_text[j])) {
+				j++
+			}
+			word := t_text[i:j]
+			switch strings.ToLower(word) {
+			case "inf", "infinity":
+				tokens = append(tokens, lpToken{kind: 'v', text: word, val: math.Inf(1), line: line})
+			default:
+				tokens = append(tokens, lpToken{kind: 'n', text: word, line: line})
+			}
+			i = j
+		default:
+			err := fmt.Sprintf("lp line %v: unexpected character: %q", line, c)
+			return []lpToken{}, errors.New(err)
+		}
+	}
+	return tokens, nil
+}
+
+// lpSections maps the keywords of an LP file to the section they open.
+var lpSections = map[string]string{
+	"minimize": "min", "minimise": "min", "minimum": "min", "min": "min",
+	"maximize": "max", "maximise": "max", "maximum": "max", "max": "max",
+	"st": "st", "s.t.": "st",
+	"bounds": "bounds", "bound": "bounds",
+	"general": "general", "generals": "general", "gen": "general",
+	"integer": "general", "integers": "general",
+	"binary": "binary", "binaries": "binary", "bin": "binary",
+	"end": "end",
+}
+
+// lpParser builds a Model from the tokens of an LP file.
+type lpParser struct {
+	tokens   []lpToken
+	pos      int
+	m        Model
+	colIndex map[string]int
+}
+
+func (p *lpParser) fail(t_msg string) error {
+	line := 0
+	if p.pos < len(p.tokens) {
+		line = p.tokens[p.pos].line
+	} else if len(p.tokens) > 0 {
+		line = p.tokens[len(p.tokens)-1].line
+	}
+	err := fmt.Sprintf("lp line %v: %v", line, t_msg)
+	return errors.New(err)
+}
+
+func (p *lpParser) peek(t_offset int) (lpToken, bool) {
+	if p.pos+t_offset >= len(p.tokens) {
+		return lpToken{}, false
+	}
+	return p.tokens[p.pos+t_offset], true
+}
+
+// section returns the section opened at the current token and the number of tokens its
+// keyword spans, or an empty string if the current token is not a keyword.
+func (p *lpParser) section() (string, int) {
+	t, ok := p.peek(0)
+	if !ok || t.kind != 'n' {
+		return "", 0
+	}
+	word := strings.ToLower(t.text)
+	if next, ok := p.peek(1); ok && next.kind == 'n' {
+		pair := word + " " + strings.ToLower(next.text)
+		if pair == "subject to" || pair == "such that" {
+			return "st", 2
+		}
+	}
+	if s, ok := lpSections[word]; ok {
+		return s, 1
+	}
+	return "", 0
+}
+
+// atEnd reports whether the current statement ends at the current token.
+func (p *lpParser) atEnd() bool {
+	if _, ok := p.peek(0); !ok {
+		return true
+	}
+	s, _ := p.section()
+	return s != ""
+}
+
+func (p *lpParser) column(t_name string) int {
+	j, ok := p.colIndex[t_name]
+	if !ok {
+		j = len(p.m.Cost)
+		p.colIndex[t_name] = j
+		p.m.ColNames = append(p.m.ColNames, t_name)
+		p.m.Cost = append(p.m.Cost, 0)
+		p.m.ColBounds = append(p.m.ColBounds, [2]float64{0, math.Inf(1)})
+		p.m.Integer = append(p.m.Integer, 0)
+	}
+	return j
+}
+
+func (p *lpParser) parse() error {
+	s, width := p.section()
+	if s != "min" && s != "max" {
+		return p.fail("expected objective sense")
+	}
+	p.pos += width
+	maximize := s == "max"
+	if err := p.objective(); err != nil {
+		return err
+	}
+
+	for {
+		s, width := p.section()
+		if s == "" {
+			if _, ok := p.peek(0); !ok {
+				break
+			}
+			return p.fail(fmt.Sprintf("unexpected token: %v", p.tokens[p.pos].text))
+		}
+		p.pos += width
+
+		var err error
+		switch s {
+		case "min", "max":
+			err = p.fail("duplicate objective")
+		case "st":
+			err = p.constraints()
+		case "bounds":
+			err = p.bounds()
+		case "general", "binary":
+			err = p.integers(s == "binary")
+		case "end":
+			p.pos = len(p.tokens)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if maximize {
+		for j := range p.m.Cost {
+			p.m.Cost[j] = -p.m.Cost[j]
+		}
+	}
+	return nil
+}
+
+// objective parses the objective following the sense keyword.
+func (p *lpParser) objective() error {
+	p.label()
+	c, _, err := p.expression()
+	if err != nil {
+		return err
+	}
+	for k, j := range c.Index {
+		p.m.Cost[j] += c.Value[k]
+	}
+	return nil
+}
+
+// constraints parses the rows of the Subject To section.
+func (p *lpParser) constraints() error {
+	for !p.atEnd() {
+		name := p.label()
+		c := NewSparseConstraint(math.Inf(-1), math.Inf(1)).Named(name)
+
+		if v, op, ok := p.leadingBound(); ok {
+			applyLPBound(&c.Lb, &c.Ub, reverseLPOp(op), v)
+		}
+
+		e, constant, err := p.expression()
+		if err != nil {
+			return err
+		}
+		c.Index, c.Value = e.Index, e.Value
+
+		op, ok := p.operator()
+		if !ok {
+			return p.fail("expected relational operator")
+		}
+		v, ok := p.number()
+		if !ok {
+			return p.fail("expected right hand side")
+		}
+		applyLPBound(&c.Lb, &c.Ub, op, v)
+		c.Lb -= constant
+		c.Ub -= constant
+		p.m.Rows = append(p.m.Rows, c)
+	}
+	return nil
+}
+
+// bounds parses the statements of the Bounds section.
+func (p *lpParser) bounds() error {
+	for !p.atEnd() {
+		if v, op, ok := p.leadingBound(); ok {
+			t, _ := p.peek(0)
+			if t.kind != 'n' {
+				return p.fail("expected column name")
+			}
+			p.pos++
+			j := p.column(t.text)
+			applyLPBound(&p.m.ColBounds[j][0], &p.m.ColBounds[j][1], reverseLPOp(op), v)
+			if op, ok := p.operator(); ok {
+				v, ok := p.number()
+				if !ok {
+					return p.fail("expected bound value")
+				}
+				applyLPBound(&p.m.ColBounds[j][0], &p.m.ColBounds[j][1], op, v)
+			}
+			continue
+		}
+
+		t, _ := p.peek(0)
+		if t.kind != 'n' {
+			return p.fail(fmt.Sprintf("unexpected token: %v", t.text))
+		}
+		p.pos++
+		j := p.column(t.text)
+
+		if next, ok := p.peek(0); ok && next.kind == 'n' && strings.ToLower(next.text) == "free" {
+			p.pos++
+			p.m.ColBounds[j] = [2]float64{math.Inf(-1), math.Inf(1)}
+			continue
+		}
+		op, ok := p.operator()
+		if !ok {
+			return p.fail("expected relational operator")
+		}
+		v, ok := p.number()
+		if !ok {
+			return p.fail("expected bound value")
+		}
+		applyLPBound(&p.m.ColBounds[j][0], &p.m.ColBounds[j][1], op, v)
+	}
+	return nil
+}
+
+// integers parses the column names of a Generals or Binaries section.
+func (p *lpParser) integers(t_binary bool) error {
+	for !p.atEnd() {
+		t, _ := p.peek(0)
+		if t.kind != 'n' {
+			return p.fail(fmt.Sprintf("expected column name, found: %v", t.text))
+		}
+		p.pos++
+		j := p.column(t.text)
+		p.m.Integer[j] = 1
+		if t_binary {
+			p.m.ColBounds[j] = [2]float64{0, 1}
+		}
+	}
+	return nil
+}
+
+// label consumes and returns a statement label, if present.
+func (p *lpParser) label() string {
+	t, ok := p.peek(0)
+	colon, ok2 := p.peek(1)
+	if ok && ok2 && t.kind == 'n' && colon.kind == ':' {
+		p.pos += 2
+		return t.text
+	}
+	return ""
+}
+
+// leadingBound consumes a value and operator that open a ranged constraint or bound.
+func (p *lpParser) leadingBound() (float64, string, bool) {
+	start := p.pos
+	v, ok := p.number()
+	if !ok {
+		return 0, "", false
+	}
+	op, ok := p.operator()
+	if !ok {
+		p.pos = start
+		return 0, "", false
+	}
+	return v, op, true
+}
+
+// number consumes a signed number.
+func (p *lpParser) number() (float64, bool) {
+	start := p.pos
+	sign := 1.0
+	for t, ok := p.peek(0); ok && t.kind == 's'; t, ok = p.peek(0) {
+		if t.text == "-" {
+			sign = -sign
+		}
+		p.pos++
+	}
+	t, ok := p.peek(0)
+	if !ok || t.kind != 'v' {
+		p.pos = start
+		return 0, false
+	}
+	p.pos++
+	return sign * t.val, true
+}
+
+// operator consumes a relational operator.
+func (p *lpParser) operator() (string, bool) {
+	t, ok := p.peek(0)
+	if !ok || t.kind != 'o' {
+		return "", false
+	}
+	p.pos++
+	return t.text, true
+}
+
+// expression consumes a linear expression, returning its terms and the sum of its
+// constant terms.
+func (p *lpParser) expression() (SparseConstraint, float64, error) {
+	e := NewSparseConstraint(0, 0)
+	constant := 0.0
+	for !p.atEnd() {
+		t, _ := p.peek(0)
+		if t.kind == 'o' {
+			break
+		}
+
+		sign := 1.0
+		for ; t.kind == 's'; t, _ = p.peek(0) {
+			if t.text == "-" {
+				sign = -sign
+			}
+			p.pos++
+			if _, ok := p.peek(0); !ok {
+				return e, 0, p.fail("expected term")
+			}
+		}
+
+		coef := 1.0
+		if t.kind == 'v' {
+			coef = t.val
+			p.pos++
+			next, ok := p.peek(0)
+			if !ok || next.kind != 'n' || p.atEnd() {
+				constant += sign * coef
+				continue
+			}
+			t = next
+		}
+		if t.kind != 'n' {
+			return e, 0, p.fail(fmt.Sprintf("unexpected token: %v", t.text))
+		}
+		if next, ok := p.peek(1); ok && next.kind == ':' {
+			return e, 0, p.fail(fmt.Sprintf("expected relational operator before label: %v", t.text))
+		}
+		p.pos++
+		e.Add(p.column(t.text), sign*coef)
+	}
+	return e, constant, nil
+}
+
+// applyLPBound applies the relation: expression t_op t_v to the bounds t_lb, t_ub.
+func applyLPBound(t_lb *float64, t_ub *float64, t_op string, t_v float64) {
+	switch t_op {
+	case "<=":
+		*t_ub = t_v
+	case ">=":
+		*t_lb = t_v
+	default:
+		*t_lb, *t_ub = t_v, t_v
+	}
+}
+
+// reverseLPOp returns the operator relating the right operand to the left.
+func reverseLPOp(t_op string) string {
+	switch t_op {
+	case "<=":
+		return ">="
+	case ">=":
+		return "<="
+	default:
+		return t_op
+	}
+}
@@ -0,0 +1,103 @@
+package cgc_optimize
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLPRoundTrip(t *testing.T) {
+	s := NewTestModelSeries()
+
+	var buf bytes.Buffer
+	err := WriteLP(&buf, &s)
+	assert.Nil(t, err)
+
+	m, err := ReadLP(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, s.CostCoefficients(), m.CostCoefficients())
+	assert.Equal(t, s.Bounds(), m.Bounds())
+	assert.Equal(t, s.Constraints(), m.Constraints())
+
+	cols, _ := fileNames(&s, lpValid, "0123456789.")
+	assert.Equal(t, cols, m.ColumnNames())
+	for _, n := range m.ColumnNames() {
+		assert.NotContains(t, n, "-")
+	}
+}
+
+func TestLPRoundTripMip(t *testing.T) {
+	inf := math.Inf(1)
+	m := Model{
+		Cost:      []float64{1, -1, 2, 0, 0},
+		ColBounds: [][2]float64{{-inf, inf}, {0, 1}, {-2, 5}, {3, 3}, {-inf, 0}},
+		Integer:   []int{0, 1, 1, 0, 0},
+		Rows: []SparseConstraint{
+			{"range", 1, 4, []int{0, 1}, []float64{1, 1}},
+			{"lower", 2, inf, []int{2}, []float64{-1.5e-7}},
+			{"free", -inf, inf, []int{0, 3}, []float64{1, 1}},
+			{"equal", -1, -1, []int{1, 2, 4}, []float64{1e-9, 3, -1}},
+			{"empty", -inf, 0, []int{}, []float64{}},
+		},
+	}
+
+	var buf bytes.Buffer
+	err := WriteLP(&buf, m)
+	assert.Nil(t, err)
+
+	rm, err := ReadLP(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, m.CostCoefficients(), rm.CostCoefficients())
+	assert.Equal(t, m.Bounds(), rm.Bounds())
+	assert.Equal(t, m.Constraints(), rm.Constraints())
+	assert.Equal(t, m.Integrality(), rm.Integrality())
+	assert.Equal(t, m.ConstraintNames(), rm.ConstraintNames())
+}
+
+func TestReadLP(t *testing.T) {
+	lp := `\ hand written
+Maximize
+ profit: 3x + 2 y - z + 4
+Subject To
+ c1: x + y <= 4
+ r2: -2 <= x - y <= 3
+ x + 3 z >= 1
+ c4: 2 y + 1 = 5
+Bounds
+ x <= 10
+ -5 <= y
+ z free
+Binaries
+ b
+End
+`
+	m, err := ReadLP(strings.NewReader(lp))
+	assert.Nil(t, err)
+
+	inf := math.Inf(1)
+	assert.Equal(t, []string{"x", "y", "z", "b"}, m.ColumnNames())
+	assert.Equal(t, []float64{-3, -2, 1, 0}, m.CostCoefficients())
+	assert.Equal(t, [][2]float64{{0, 10}, {-5, inf}, {-inf, inf}, {0, 1}}, m.Bounds())
+	assert.Equal(t, []int{0, 0, 0, 1}, m.Integrality())
+	assert.Equal(t, []string{"c1", "r2", "c2", "c4"}, m.ConstraintNames())
+	assert.Equal(t, [][]float64{
+		{-inf, 1, 1, 0, 0, 4},
+		{-2, 1, -1, 0, 0, 3},
+		{1, 1, 0, 3, 0, inf},
+		{4, 0, 2, 0, 0, 4},
+	}, m.Constraints())
+}
+
+func TestReadLPErrors(t *testing.T) {
+	_, err := ReadLP(strings.NewReader("Subject To\n c1: x >= 1\nEnd\n"))
+	assert.Error(t, err)
+
+	_, err = ReadLP(strings.NewReader("Minimize\n x\nSubject To\n c1: x 1\nEnd\n"))
+	assert.Error(t, err)
+
+	_, err = ReadLP(strings.NewReader("Minimize\n x\nSubject To\n c1: x >= 1 [\nEnd\n"))
+	assert.Error(t, err)
+}
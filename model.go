@@ -0,0 +1,123 @@
+package cgc_optimize
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Model is a linear program held as plain data. It is the form in which programs are
+// read from MPS and LP files, and satisfies LinearProgram, MipLinearProgram and
+// SparseLinearProgram.
+type Model struct {
+	Name      string
+	Cost      []float64
+	ColBounds [][2]float64
+	Rows      []SparseConstraint
+	Integer   []int
+	ColNames  []string
+}
+
+var _ MipLinearProgram = Model{}
+var _ SparseLinearProgram = Model{}
+
+// columnNamer is implemented by programs that name their columns
+type columnNamer interface {
+	ColumnNames() []string
+}
+
+// NewModel returns a copy of w as a Model, keeping column and row names where w
+// provides them.
+func NewModel(w LinearProgram) Model {
+	m := Model{
+		Cost:      append([]float64{}, w.CostCoefficients()...),
+		ColBounds: append([][2]float64{}, w.Bounds()...),
+		Rows:      append([]SparseConstraint{}, SparseConstraintsOf(w)...),
+		Integer:   make([]int, len(w.CostCoefficients())),
+	}
+
+	if mw, ok := w.(MipLinearProgram); ok {
+		copy(m.Integer, mw.Integrality())
+	}
+	if nw, ok := w.(columnNamer); ok {
+		m.ColNames = append([]string{}, nw.ColumnNames()...)
+	}
+
+	return m
+}
+
+func (m Model) CostCoefficients() []float64 {
+	return m.Cost
+}
+
+func (m Model) Bounds() [][2]float64 {
+	return m.ColBounds
+}
+
+func (m Model) Constraints() [][]float64 {
+	return densify(len(m.Cost), m.Rows)
+}
+
+func (m Model) SparseConstraints() []SparseConstraint {
+	return m.Rows
+}
+
+func (m Model) Integrality() []int {
+	if len(m.Integer) != len(m.Cost) {
+		return make([]int, len(m.Cost))
+	}
+	return m.Integer
+}
+
+// ColumnNames returns the name of each column, naming unnamed columns by index.
+func (m Model) ColumnNames() []string {
+	names := make([]string, len(m.Cost))
+	for j := range names {
+		if j < len(m.ColNames) {
+			names[j] = m.ColNames[j]
+		}
+		if names[j] == "" {
+			names[j] = fmt.Sprintf("x%v", j)
+		}
+	}
+	return names
+}
+
+// ConstraintNames returns the name of each constraint row.
+func (m Model) ConstraintNames() []string {
+	return constraintNames(m.Rows)
+}
+
+// fileNames returns unique column and row names for w, with every character outside
+// t_valid replaced by an underscore. Names that are empty, start with a character in
+// t_noLead or repeat an earlier name are replaced or suffixed.
+func fileNames(w LinearProgram, t_valid func(rune) bool, t_noLead string) ([]string, []string) {
+	m := NewModel(w)
+	cols := m.ColumnNames()
+	rows := m.ConstraintNames()
+
+	seen := map[string]bool{"obj": true}
+	clean := func(t_name string, t_default string) string {
+		n := strings.Map(func(r rune) rune {
+			if t_valid(r) {
+				return r
+			}
+			return '_'
+		}, t_name)
+		if n == "" || strings.ContainsRune(t_noLead, rune(n[0])) {
+			n = t_default + "_" + n
+		}
+		for base, k := n, 1; seen[n]; k++ {
+			n = fmt.Sprintf("%v_%v", base, k)
+		}
+		seen[n] = true
+		return n
+	}
+
+	for j := range cols {
+		cols[j] = clean(cols[j], "x")
+	}
+	for i := range rows {
+		rows[i] = clean(rows[i], "c")
+	}
+	return cols, rows
+}
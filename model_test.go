@@ -0,0 +1,62 @@
+package cgc_optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func NewTestModelSeries() Series {
+	pid1, _ := uuid.NewUUID()
+	pid2, _ := uuid.NewUUID()
+	inf := math.Inf(1)
+	a1 := NewBasicUnit(pid1, 1, -2, 0.5, 0, 10, 10, 20, 40)
+	a1.NewSparseConstraint(BasicUnitCapacityConstraints(&a1)...)
+	a2 := NewBasicUnit(pid2, 5, 6, 7, 8, inf, inf, inf, inf)
+	g := NewGroup(&a1, &a2)
+	g.NewSparseConstraint(NetLoadConstraint(&g, 3))
+	cl := NewCluster(g)
+
+	s := NewSeries(&cl, &cl)
+	s.NewSparseConstraint(BatteryEnergyConstraint(&s, pid1, 0.25)...)
+	s.NewSparseConstraint(BatteryInitialEnergyConstraint(&s, pid1, 20))
+	s.NewConstraint(append(append([]float64{-1}, make([]float64, s.ColumnSize())...), 2))
+	return s
+}
+
+func TestNewModel(t *testing.T) {
+	s := NewTestModelSeries()
+	m := NewModel(&s)
+
+	assert.Equal(t, s.CostCoefficients(), m.CostCoefficients())
+	assert.Equal(t, s.Bounds(), m.Bounds())
+	assert.Equal(t, s.Constraints(), m.Constraints())
+	assert.Equal(t, s.ColumnNames(), m.ColumnNames())
+	assert.Equal(t, s.ConstraintNames(), m.ConstraintNames())
+	assert.Equal(t, make([]int, s.ColumnSize()), m.Integrality())
+}
+
+func TestModelDefaultNames(t *testing.T) {
+	m := Model{
+		Cost:      []float64{1, 2},
+		ColBounds: [][2]float64{{0, 1}, {0, 1}},
+		Rows:      []SparseConstraint{NewSparseConstraint(0, 1)},
+	}
+	assert.Equal(t, []string{"x0", "x1"}, m.ColumnNames())
+	assert.Equal(t, []string{"c0"}, m.ConstraintNames())
+	assert.Equal(t, []int{0, 0}, m.Integrality())
+}
+
+func TestFileNames(t *testing.T) {
+	m := Model{
+		Cost:      []float64{1, 2, 3},
+		ColBounds: [][2]float64{{0, 1}, {0, 1}, {0, 1}},
+		ColNames:  []string{"9a-b", "x", "x"},
+		Rows:      []SparseConstraint{NewSparseConstraint(0, 1).Named("obj")},
+	}
+	cols, rows := fileNames(m, lpValid, "0123456789.")
+	assert.Equal(t, []string{"x_9a_b", "x", "x_1"}, cols)
+	assert.Equal(t, []string{"obj_1"}, rows)
+}
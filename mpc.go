@@ -0,0 +1,226 @@
+package cgc_optimize
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Clock is the time source of an MPC loop. Sleep returns early with the error of the
+// context if it is done first.
+type Clock interface {
+	Now() time.Time
+	Sleep(context.Context, time.Duration) error
+}
+
+// SystemClock is the wall clock.
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (SystemClock) Sleep(t_ctx context.Context, t_d time.Duration) error {
+	t := time.NewTimer(t_d)
+	defer t.Stop()
+
+	select {
+	case <-t_ctx.Done():
+		return t_ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// Plant is the site under control. It reports the measured stored energy of each storage
+// unit, and applies the setpoints of the first time step of each solve.
+type Plant interface {
+	StoredEnergy(uuid.UUID) (float64, error)
+	Dispatch(time.Time, StepResult) error
+}
+
+// Forecaster returns the forecasts of a horizon beginning at t_start.
+type Forecaster interface {
+	Forecast(t_start time.Time) (Forecasts, error)
+}
+
+// Forecasts are the forecasts of a horizon, as described by Horizon.
+type Forecasts struct {
+	NetLoad Forecast
+	PV      map[uuid.UUID]Forecast
+	Tariff  map[uuid.UUID]Forecast
+}
+
+// Forecast returns f. Forecasts covering a whole simulation are their own Forecaster, as
+// each horizon is windowed from them.
+func (f Forecasts) Forecast(t_start time.Time) (Forecasts, error) {
+	return f, nil
+}
+
+// MPC re-solves the dispatch of a horizon at every time step, and dispatches the plant
+// to the setpoints of the first step.
+type MPC struct {
+	horizon    Horizon
+	solver     Solver
+	opts       SolverOptions
+	clock      Clock
+	plant      Plant
+	forecaster Forecaster
+	logger     *log.Logger
+
+	previous      []float64 // column solution of the previous solve
+	previousStart time.Time
+}
+
+// NewMPC returns an MPC loop over horizons of t_h.Steps time steps of t_h.Step. The
+// template and storage of t_h are used at every step; its start time and forecasts are
+// replaced by the clock and forecaster. Each solve is warm started from the previous
+// solution, shifted to the new start time. A warm start that cannot be shifted is dropped
+// and reported to the standard logger, or the logger of SetLogger.
+func NewMPC(t_h Horizon, t_solver Solver, t_opts SolverOptions, t_clock Clock, t_plant Plant, t_f Forecaster) *MPC {
+	return &MPC{
+		horizon:    t_h,
+		solver:     t_solver,
+		opts:       t_opts,
+		clock:      t_clock,
+		plant:      t_plant,
+		forecaster: t_f,
+		logger:     log.Default(),
+	}
+}
+
+// SetLogger sets the logger of the reports of the loop.
+func (m *MPC) SetLogger(t_l *log.Logger) {
+	m.logger = t_l
+}
+
+// Step solves the horizon beginning at the current time step, the clock time truncated to
+// a multiple of the step length, with the stored energy of each storage unit as measured
+// by the plant. The plant is dispatched to the setpoints of the first time step, which
+// are returned.
+func (m *MPC) Step() (StepResult, error) {
+	h := m.horizon
+	h.Start = m.clock.Now().Truncate(h.Step)
+
+	fx, err := m.forecaster.Forecast(h.Start)
+	if err != nil {
+		return nil, err
+	}
+	h.NetLoad, h.PV, h.Tariff = fx.NetLoad, fx.PV, fx.Tariff
+
+	h.Storage = make(map[uuid.UUID]SiteStorage)
+	for pid, ss := range m.horizon.Storage {
+		e, err := m.plant.StoredEnergy(pid)
+		if err != nil {
+			return nil, err
+		}
+		ss.InitialEnergy = &e
+		h.Storage[pid] = ss
+	}
+
+	se, err := NewHorizonSeries(h)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := m.opts
+	opts.WarmStart, err = m.shift(h.Start, se.ColumnSize(), len(se.aux))
+	if err != nil {
+		m.logger.Printf("mpc: warm start dropped at %v: %v", h.Start, err)
+	}
+
+	var res Result
+	if isMip(se.Integrality()) {
+		res, err = m.solver.SolveMip(se, opts)
+	} else {
+		res, err = m.solver.SolveLp(se, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	srx, err := se.Decode(res.Columns)
+	if err != nil {
+		return nil, err
+	}
+	m.previous, m.previousStart = res.Columns, h.Start
+
+	if err := m.plant.Dispatch(h.Start, srx[0]); err != nil {
+		return nil, err
+	}
+	return srx[0], nil
+}
+
+// Run steps the loop at the beginning of each time step until t_ctx is done, including
+// while waiting for the next step. A failed step stops the loop with its error.
+func (m *MPC) Run(t_ctx context.Context) error {
+	for {
+		if _, err := m.Step(); err != nil {
+			return err
+		}
+
+		if err := t_ctx.Err(); err != nil {
+			return err
+		}
+
+		now := m.clock.Now()
+		next := now.Truncate(m.horizon.Step).Add(m.horizon.Step)
+		if err := m.clock.Sleep(t_ctx, next.Sub(now)); err != nil {
+			return err
+		}
+	}
+}
+
+// shift returns the previous solution moved forward to a horizon beginning at t_start,
+// with the final time step repeated to fill the horizon. The last t_aux columns are the
+// auxiliary columns of the series, which span the horizon and are kept as they were. It
+// returns nil without an error if there is no previous solution, and an error if the
+// previous solution cannot be shifted to the horizon.
+func (m *MPC) shift(t_start time.Time, t_size int, t_aux int) ([]float64, error) {
+	if m.previous == nil {
+		return nil, nil
+	}
+
+	n := m.horizon.Steps
+	size := t_size - t_aux
+	if len(m.previous) != t_size {
+		err := fmt.Sprintf("previous solution contains %v columns, expected: %v", len(m.previous), t_size)
+		return nil, errors.New(err)
+	}
+	if size < 0 || size%n != 0 {
+		err := fmt.Sprintf("%v step columns are not divisible into %v equal time steps", size, n)
+		return nil, errors.New(err)
+	}
+	if t_start.Before(m.previousStart) {
+		err := fmt.Sprintf("horizon start %v is before the previous start %v", t_start, m.previousStart)
+		return nil, errors.New(err)
+	}
+
+	k := int(t_start.Sub(m.previousStart) / m.horizon.Step)
+	if k >= n {
+		err := fmt.Sprintf("previous solution does not overlap the horizon, %v steps after its start", k)
+		return nil, errors.New(err)
+	}
+
+	step := size / n
+	x := make([]float64, 0, t_size)
+	x = append(x, m.previous[k*step:size]...)
+	for len(x) < size {
+		x = append(x, m.previous[size-step:size]...)
+	}
+	return append(x, m.previous[size:]...), nil
+}
+
+// isMip returns true if t_intg marks any integer column.
+func isMip(t_intg []int) bool {
+	for _, v := range t_intg {
+		if v != 0 {
+			return true
+		}
+	}
+	return false
+}
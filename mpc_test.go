@@ -0,0 +1,159 @@
+package cgc_optimize
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// testClock is a deterministic clock, advanced only by Sleep
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time { return c.now }
+
+func (c *testClock) Sleep(t_ctx context.Context, t_d time.Duration) error {
+	c.now = c.now.Add(t_d)
+	return t_ctx.Err()
+}
+
+// testPlant simulates a battery charged and discharged by the dispatched setpoints
+type testPlant struct {
+	ess      uuid.UUID
+	energy   float64
+	step     time.Duration
+	dispatch []StepResult
+	cancel   func()
+	steps    int
+}
+
+func (p *testPlant) StoredEnergy(t_pid uuid.UUID) (float64, error) {
+	return p.energy, nil
+}
+
+func (p *testPlant) Dispatch(t_t time.Time, t_sr StepResult) error {
+	r := t_sr[p.ess]
+	p.energy += (r.RealNegativePower - r.RealPositivePower) * p.step.Hours()
+	p.dispatch = append(p.dispatch, t_sr)
+	if len(p.dispatch) == p.steps {
+		p.cancel()
+	}
+	return nil
+}
+
+func TestMPCRun(t *testing.T) {
+	inf := math.Inf(1)
+	gridPID, _ := uuid.NewUUID()
+	essPID, _ := uuid.NewUUID()
+	grid := NewBasicUnit(gridPID, 0, 0, 0, 0, inf, 0, 0, 0)
+	// a small charge cost keeps the battery from cycling energy it does not need
+	ess := NewBasicUnit(essPID, 0, 0.01, 0, 0, 10, 10, 0, 10)
+
+	start := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	step := 5 * time.Minute
+	h := Horizon{
+		Template: NewGroup(grid, ess),
+		Step:     step,
+		Steps:    4,
+		Storage:  map[uuid.UUID]SiteStorage{essPID: {}},
+	}
+	fx := Forecasts{
+		NetLoad: Forecast{start, step, []float64{6, 6, 6, 6, 6, 6, 6, 6}},
+		Tariff:  map[uuid.UUID]Forecast{gridPID: {start, step, []float64{1, 1, 5, 5, 1, 1, 1, 1}}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	plant := &testPlant{ess: essPID, energy: 1, step: step, cancel: cancel, steps: 4}
+	clock := &testClock{start.Add(time.Minute)}
+	m := NewMPC(h, NativeSolver{}, SolverOptions{}, clock, plant, fx)
+
+	err := m.Run(ctx)
+	assert.Equal(t, context.Canceled, err)
+	assert.Len(t, plant.dispatch, 4)
+	assert.Equal(t, start.Add(3*step), clock.now)
+
+	// the battery covers the expensive steps, charging beforehand as needed
+	for k := 2; k < 4; k++ {
+		assert.InDelta(t, 0, plant.dispatch[k][gridPID].RealPositivePower, 1e-6)
+		assert.InDelta(t, 6, plant.dispatch[k][essPID].RealPositivePower, 1e-6)
+	}
+	assert.InDelta(t, 0, plant.energy, 1e-6)
+}
+
+func TestMPCShift(t *testing.T) {
+	start := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	m := MPC{horizon: Horizon{Step: time.Hour, Steps: 3}}
+	x, err := m.shift(start, 6, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, x)
+
+	m.previous, m.previousStart = []float64{1, 2, 3, 4, 5, 6}, start
+	for k, expected := range [][]float64{{1, 2, 3, 4, 5, 6}, {3, 4, 5, 6, 5, 6}, {5, 6, 5, 6, 5, 6}} {
+		x, err := m.shift(start.Add(time.Duration(k)*time.Hour), 6, 0)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, x)
+	}
+
+	// a previous solution that cannot be shifted is reported
+	for _, start := range []time.Time{start.Add(3 * time.Hour), start.Add(-time.Hour)} {
+		x, err := m.shift(start, 6, 0)
+		assert.Error(t, err)
+		assert.Nil(t, x)
+	}
+	x, err = m.shift(start, 9, 0)
+	assert.Error(t, err)
+	assert.Nil(t, x)
+
+	// auxiliary columns follow the time steps and are kept
+	m.previous = []float64{1, 2, 3, 4, 5, 6, 7}
+	x, err = m.shift(start.Add(time.Hour), 7, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{3, 4, 5, 6, 5, 6, 7}, x)
+
+	// steps of unequal size cannot be shifted
+	x, err = m.shift(start, 7, 0)
+	assert.Error(t, err)
+	assert.Nil(t, x)
+}
+
+func TestMPCDroppedWarmStart(t *testing.T) {
+	gridPID, _ := uuid.NewUUID()
+	grid := NewBasicUnit(gridPID, 0, 0, 0, 0, math.Inf(1), 0, 0, 0)
+
+	start := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	h := Horizon{Template: NewGroup(grid), Step: time.Hour, Steps: 2}
+	fx := Forecasts{NetLoad: Forecast{start, time.Hour, []float64{1, 2}}}
+	m := NewMPC(h, NativeSolver{}, SolverOptions{}, &testClock{start}, &testPlant{cancel: func() {}}, fx)
+
+	var buf bytes.Buffer
+	m.SetLogger(log.New(&buf, "", 0))
+
+	// the first solve has no warm start to drop
+	_, err := m.Step()
+	assert.Nil(t, err)
+	assert.Empty(t, buf.String())
+
+	m.previous = m.previous[:1]
+	sr, err := m.Step()
+	assert.Nil(t, err)
+	assert.InDelta(t, 1, sr[gridPID].RealPositivePower, 1e-6)
+	assert.Contains(t, buf.String(), "warm start dropped")
+}
+
+func TestSystemClockSleep(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.Nil(t, SystemClock{}.Sleep(ctx, time.Millisecond))
+
+	// a cancelled context ends the sleep
+	cancel()
+	begin := time.Now()
+	assert.Equal(t, context.Canceled, SystemClock{}.Sleep(ctx, time.Hour))
+	assert.Less(t, int64(time.Since(begin)), int64(time.Minute))
+}
@@ -0,0 +1,422 @@
+package cgc_optimize
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// mpsInf is the magnitude at and above which an MPS value is read as infinite.
+const mpsInf = 1e30
+
+// WriteMPS writes w to t_w in free MPS format. Rows and columns take the names reported
+// by w, with whitespace replaced and duplicates suffixed; unnamed columns are written as
+// x<j> and unnamed rows as c<i>. Columns with a nonzero Integrality() are written between
+// integer markers with explicit bounds.
+func WriteMPS(t_w io.Writer, w LinearProgram) error {
+	m := NewModel(w)
+	cols, rows := fileNames(w, mpsValid, "")
+	n := len(m.Cost)
+	if len(m.ColBounds) != n {
+		err := fmt.Sprintf("program contains %v bounds, expected: %v", len(m.ColBounds), n)
+		return errors.New(err)
+	}
+	if err := validateSparse(n, m.Rows); err != nil {
+		return err
+	}
+
+	name := "cgc_optimize"
+	if mw, ok := w.(Model); ok && mw.Name != "" {
+		name = strings.Map(mpsRune, mw.Name)
+	}
+
+	b := bufio.NewWriter(t_w)
+	fmt.Fprintf(b, "NAME %v\n", name)
+
+	fmt.Fprintln(b, "ROWS")
+	fmt.Fprintln(b, " N obj")
+	for i, c := range m.Rows {
+		fmt.Fprintf(b, " %v %v\n", mpsRowType(c), rows[i])
+	}
+
+	fmt.Fprintln(b, "COLUMNS")
+	start, index, value := CSC(n, m.Rows)
+	integer := false
+	for j := range cols {
+		if (m.Integer[j] != 0) != integer {
+			integer = !integer
+			marker := "'INTEND'"
+			if integer {
+				marker = "'INTORG'"
+			}
+			fmt.Fprintf(b, "    MARKER 'MARKER' %v\n", marker)
+		}
+		if m.Cost[j] != 0 || start[j] == start[j+1] {
+			fmt.Fprintf(b, "    %v obj %v\n", cols[j], formatNumber(m.Cost[j]))
+		}
+		for k := start[j]; k < start[j+1]; k++ {
+			fmt.Fprintf(b, "    %v %v %v\n", cols[j], rows[index[k]], formatNumber(value[k]))
+		}
+	}
+	if integer {
+		fmt.Fprintln(b, "    MARKER 'MARKER' 'INTEND'")
+	}
+
+	fmt.Fprintln(b, "RHS")
+	for i, c := range m.Rows {
+		if rhs := mpsRhs(c); rhs != 0 {
+			fmt.Fprintf(b, "    rhs %v %v\n", rows[i], formatNumber(rhs))
+		}
+	}
+
+	fmt.Fprintln(b, "RANGES")
+	for i, c := range m.Rows {
+		if mpsRowType(c) == "L" && !math.IsInf(c.Lb, -1) {
+			fmt.Fprintf(b, "    rng %v %v\n", rows[i], formatNumber(c.Ub-c.Lb))
+		}
+	}
+
+	fmt.Fprintln(b, "BOUNDS")
+	for j, bnd := range m.ColBounds {
+		lo, up := bnd[0], bnd[1]
+		switch {
+		case lo == up:
+			fmt.Fprintf(b, " FX bnd %v %v\n", cols[j], formatNumber(lo))
+			continue
+		case math.IsInf(lo, -1) && math.IsInf(up, 1):
+			fmt.Fprintf(b, " FR bnd %v\n", cols[j])
+			continue
+		}
+
+		if math.IsInf(lo, -1) {
+			fmt.Fprintf(b, " MI bnd %v\n", cols[j])
+		} else if lo != 0 || m.Integer[j] != 0 {
+			fmt.Fprintf(b, " LO bnd %v %v\n", cols[j], formatNumber(lo))
+		}
+		if !math.IsInf(up, 1) {
+			fmt.Fprintf(b, " UP bnd %v %v\n", cols[j], formatNumber(up))
+		} else if m.Integer[j] != 0 {
+			fmt.Fprintf(b, " PL bnd %v\n", cols[j])
+		}
+	}
+
+	fmt.Fprintln(b, "ENDATA")
+	return b.Flush()
+}
+
+// ReadMPS reads a linear program in free MPS format. The first N row is the objective
+// and any further N rows are read as free constraints. An OBJSENSE of MAX negates the
+// costs, as a Model is always minimized.
+func ReadMPS(t_r io.Reader) (Model, error) {
+	m := Model{}
+	rowIndex := make(map[string]int)
+	rowType := make([]string, 0)
+	rhs := make([]float64, 0)
+	rng := make([]float64, 0)
+	ranged := make([]bool, 0)
+	colIndex := make(map[string]int)
+	lowerSet := make([]bool, 0)
+	objective := ""
+	maximize := false
+	integer := false
+
+	column := func(t_name string) int {
+		j, ok := colIndex[t_name]
+		if !ok {
+			j = len(m.Cost)
+			colIndex[t_name] = j
+			m.ColNames = append(m.ColNames, t_name)
+			m.Cost = append(m.Cost, 0)
+			m.ColBounds = append(m.ColBounds, [2]float64{0, math.Inf(1)})
+			m.Integer = append(m.Integer, 0)
+			lowerSet = append(lowerSet, false)
+		}
+		return j
+	}
+
+	s := bufio.NewScanner(t_r)
+	s.Buffer(make([]byte, 0, 64*1024), 1024*1024*1024)
+	section := ""
+	line := 0
+scan:
+	for s.Scan() {
+		line++
+		text := s.Text()
+		fields := strings.Fields(text)
+		if len(fields) == 0 || strings.HasPrefix(text, "*") {
+			continue
+		}
+		fail := func(t_msg string) (Model, error) {
+			err := fmt.Sprintf("mps line %v: %v", line, t_msg)
+			return Model{}, errors.New(err)
+		}
+
+		if text[0] != ' ' && text[0] != '\t' {
+			section = strings.ToUpper(fields[0])
+			switch section {
+			case "NAME":
+				if len(fields) > 1 {
+					m.Name = fields[1]
+				}
+			case "OBJSENSE":
+				if len(fields) > 1 {
+					maximize = strings.HasPrefix(strings.ToUpper(fields[1]), "MAX")
+				}
+			case "ENDATA":
+				break scan
+			case "ROWS", "COLUMNS", "RHS", "RANGES", "BOUNDS":
+			default:
+				return fail(fmt.Sprintf("unsupported section: %v", fields[0]))
+			}
+			continue
+		}
+
+		switch section {
+		case "OBJSENSE":
+			maximize = strings.HasPrefix(strings.ToUpper(fields[0]), "MAX")
+
+		case "ROWS":
+			if len(fields) != 2 {
+				return fail("expected row type and name")
+			}
+			t := strings.ToUpper(fields[0])
+			switch t {
+			case "N":
+				if objective == "" {
+					objective = fields[1]
+					continue
+				}
+			case "E", "L", "G":
+			default:
+				return fail(fmt.Sprintf("unknown row type: %v", fields[0]))
+			}
+			if _, ok := rowIndex[fields[1]]; ok {
+				return fail(fmt.Sprintf("duplicate row: %v", fields[1]))
+			}
+			rowIndex[fields[1]] = len(m.Rows)
+			m.Rows = append(m.Rows, NewSparseConstraint(0, 0).Named(fields[1]))
+			rowType = append(rowType, t)
+			rhs = append(rhs, 0)
+			rng = append(rng, 0)
+			ranged = append(ranged, false)
+
+		case "COLUMNS":
+			if len(fields) >= 3 && strings.Trim(fields[1], "'") == "MARKER" {
+				switch strings.Trim(fields[2], "'") {
+				case "INTORG":
+					integer = true
+				case "INTEND":
+					integer = false
+				default:
+					return fail(fmt.Sprintf("unknown marker: %v", fields[2]))
+				}
+				continue
+			}
+			if len(fields) != 3 && len(fields) != 5 {
+				return fail("expected column name and one or two row entries")
+			}
+			j := column(fields[0])
+			if integer {
+				m.Integer[j] = 1
+			}
+			for k := 1; k < len(fields); k += 2 {
+				v, err := parseNumber(fields[k+1])
+				if err != nil {
+					return fail(err.Error())
+				}
+				if fields[k] == objective {
+					m.Cost[j] += v
+					continue
+				}
+				i, ok := rowIndex[fields[k]]
+				if !ok {
+					return fail(fmt.Sprintf("unknown row: %v", fields[k]))
+				}
+				m.Rows[i].Add(j, v)
+			}
+
+		case "RHS", "RANGES":
+			entries := fields
+			if len(entries)%2 == 1 {
+				entries = entries[1:]
+			}
+			for k := 0; k < len(entries); k += 2 {
+				v, err := parseNumber(entries[k+1])
+				if err != nil {
+					return fail(err.Error())
+				}
+				if entries[k] == objective {
+					continue
+				}
+				i, ok := rowIndex[entries[k]]
+				if !ok {
+					return fail(fmt.Sprintf("unknown row: %v", entries[k]))
+				}
+				if section == "RHS" {
+					rhs[i] = v
+				} else {
+					rng[i], ranged[i] = v, true
+				}
+			}
+
+		case "BOUNDS":
+			t := strings.ToUpper(fields[0])
+			valued := t != "FR" && t != "MI" && t != "PL" && t != "BV"
+			entries := fields[1:]
+			if (valued && len(entries) == 3) || (!valued && len(entries) == 2) {
+				entries = entries[1:]
+			} else if !valued && len(entries) == 3 {
+				entries = entries[1:2]
+			}
+			if len(entries) == 0 || (valued && len(entries) != 2) {
+				return fail("expected bound type, column name and value")
+			}
+			j, ok := colIndex[entries[0]]
+			if !ok {
+				return fail(fmt.Sprintf("unknown column: %v", entries[0]))
+			}
+			v := 0.0
+			if valued {
+				var err error
+				if v, err = parseNumber(entries[1]); err != nil {
+					return fail(err.Error())
+				}
+			}
+			switch t {
+			case "UP", "UI":
+				m.ColBounds[j][1] = v
+				if v < 0 && m.ColBounds[j][0] == 0 && !lowerSet[j] {
+					m.ColBounds[j][0] = math.Inf(-1)
+				}
+			case "LO", "LI":
+				m.ColBounds[j][0], lowerSet[j] = v, true
+			case "FX":
+				m.ColBounds[j] = [2]float64{v, v}
+				lowerSet[j] = true
+			case "FR":
+				m.ColBounds[j] = [2]float64{math.Inf(-1), math.Inf(1)}
+				lowerSet[j] = true
+			case "MI":
+				m.ColBounds[j][0], lowerSet[j] = math.Inf(-1), true
+			case "PL":
+				m.ColBounds[j][1] = math.Inf(1)
+			case "BV":
+				m.ColBounds[j] = [2]float64{0, 1}
+				lowerSet[j] = true
+			default:
+				return fail(fmt.Sprintf("unknown bound type: %v", fields[0]))
+			}
+			if t == "UI" || t == "LI" || t == "BV" {
+				m.Integer[j] = 1
+			}
+
+		default:
+			return fail("data outside of a section")
+		}
+	}
+	if err := s.Err(); err != nil {
+		return Model{}, err
+	}
+
+	for i := range m.Rows {
+		r := math.Abs(rng[i])
+		switch rowType[i] {
+		case "N":
+			m.Rows[i].Lb, m.Rows[i].Ub = math.Inf(-1), math.Inf(1)
+		case "E":
+			m.Rows[i].Lb, m.Rows[i].Ub = rhs[i], rhs[i]
+			if ranged[i] && rng[i] > 0 {
+				m.Rows[i].Ub = rhs[i] + r
+			} else if ranged[i] {
+				m.Rows[i].Lb = rhs[i] - r
+			}
+		case "L":
+			m.Rows[i].Lb, m.Rows[i].Ub = math.Inf(-1), rhs[i]
+			if ranged[i] {
+				m.Rows[i].Lb = rhs[i] - r
+			}
+		case "G":
+			m.Rows[i].Lb, m.Rows[i].Ub = rhs[i], math.Inf(1)
+			if ranged[i] {
+				m.Rows[i].Ub = rhs[i] + r
+			}
+		}
+	}
+
+	if maximize {
+		for j := range m.Cost {
+			m.Cost[j] = -m.Cost[j]
+		}
+	}
+	return m, nil
+}
+
+// mpsRowType returns the MPS type of a constraint row. Rows bounded on both sides are
+// written as L rows with a range.
+func mpsRowType(t_c SparseConstraint) string {
+	switch {
+	case t_c.Lb == t_c.Ub:
+		return "E"
+	case !math.IsInf(t_c.Ub, 1):
+		return "L"
+	case !math.IsInf(t_c.Lb, -1):
+		return "G"
+	default:
+		return "N"
+	}
+}
+
+// mpsRhs returns the right hand side of a constraint row in MPS form.
+func mpsRhs(t_c SparseConstraint) float64 {
+	switch mpsRowType(t_c) {
+	case "E", "G":
+		return t_c.Lb
+	case "L":
+		return t_c.Ub
+	default:
+		return 0
+	}
+}
+
+func mpsValid(r rune) bool {
+	return r > ' ' && r <= '~' && r != '$' && r != '*'
+}
+
+func mpsRune(r rune) rune {
+	if mpsValid(r) {
+		return r
+	}
+	return '_'
+}
+
+// formatNumber formats t_v with the fewest digits that read back exactly.
+func formatNumber(t_v float64) string {
+	switch {
+	case math.IsInf(t_v, 1):
+		return "inf"
+	case math.IsInf(t_v, -1):
+		return "-inf"
+	default:
+		return strconv.FormatFloat(t_v, 'g', -1, 64)
+	}
+}
+
+// parseNumber parses a number from a model file, reading magnitudes of at least 1e30
+// as infinite.
+func parseNumber(t_s string) (float64, error) {
+	v, err := strconv.ParseFloat(t_s, 64)
+	if err != nil {
+		err := fmt.Sprintf("invalid number: %v", t_s)
+		return 0, errors.New(err)
+	}
+	if v >= mpsInf {
+		return math.Inf(1), nil
+	} else if v <= -mpsInf {
+		return math.Inf(-1), nil
+	}
+	return v, nil
+}
@@ -0,0 +1,117 @@
+package cgc_optimize
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMPSRoundTrip(t *testing.T) {
+	s := NewTestModelSeries()
+
+	var buf bytes.Buffer
+	err := WriteMPS(&buf, &s)
+	assert.Nil(t, err)
+
+	m, err := ReadMPS(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, s.CostCoefficients(), m.CostCoefficients())
+	assert.Equal(t, s.Bounds(), m.Bounds())
+	assert.Equal(t, s.Constraints(), m.Constraints())
+	assert.Equal(t, s.ColumnNames(), m.ColumnNames())
+	assert.Equal(t, s.ConstraintNames(), m.ConstraintNames())
+
+	want, err := NativeSolver{}.SolveLp(&s, SolverOptions{})
+	assert.Nil(t, err)
+	got, err := NativeSolver{}.SolveLp(m, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, want.Objective, got.Objective, 1e-9)
+}
+
+func TestMPSRoundTripMip(t *testing.T) {
+	inf := math.Inf(1)
+	m := Model{
+		Name:      "mip",
+		Cost:      []float64{1, -1, 2, 0},
+		ColBounds: [][2]float64{{-inf, inf}, {0, 1}, {-2, 5}, {3, 3}},
+		Integer:   []int{0, 1, 1, 0},
+		Rows: []SparseConstraint{
+			{"range", 1, 4, []int{0, 1}, []float64{1, 1}},
+			{"lower", 2, inf, []int{2}, []float64{-1.5}},
+			{"free", -inf, inf, []int{0, 3}, []float64{1, 1}},
+			{"equal", -1, -1, []int{1, 2}, []float64{1e-9, 3}},
+		},
+	}
+
+	var buf bytes.Buffer
+	err := WriteMPS(&buf, m)
+	assert.Nil(t, err)
+
+	rm, err := ReadMPS(&buf)
+	assert.Nil(t, err)
+	assert.Equal(t, m.Name, rm.Name)
+	assert.Equal(t, m.CostCoefficients(), rm.CostCoefficients())
+	assert.Equal(t, m.Bounds(), rm.Bounds())
+	assert.Equal(t, m.Constraints(), rm.Constraints())
+	assert.Equal(t, m.Integrality(), rm.Integrality())
+	assert.Equal(t, m.ConstraintNames(), rm.ConstraintNames())
+}
+
+func TestReadMPS(t *testing.T) {
+	mps := `* hand written
+NAME example
+OBJSENSE
+    MAX
+ROWS
+ N cost
+ L lim1
+ G lim2
+ E myeqn
+COLUMNS
+    MARKER 'MARKER' 'INTORG'
+    x1 cost 1 lim1 1
+    x1 lim2 1
+    MARKER 'MARKER' 'INTEND'
+    x2 cost 2 lim1 1
+    x2 myeqn -1
+    x3 cost -1 myeqn 1
+RHS
+    rhs lim1 4 lim2 1
+    rhs myeqn 7
+RANGES
+    rng myeqn -2
+BOUNDS
+ UP bnd x1 4
+ MI bnd x2
+ UP bnd x2 1
+ UP bnd x3 -1
+ENDATA
+`
+	m, err := ReadMPS(strings.NewReader(mps))
+	assert.Nil(t, err)
+
+	inf := math.Inf(1)
+	assert.Equal(t, "example", m.Name)
+	assert.Equal(t, []float64{-1, -2, 1}, m.CostCoefficients())
+	assert.Equal(t, [][2]float64{{0, 4}, {-inf, 1}, {-inf, -1}}, m.Bounds())
+	assert.Equal(t, []int{1, 0, 0}, m.Integrality())
+	assert.Equal(t, [][]float64{
+		{-inf, 1, 1, 0, 4},
+		{1, 1, 0, 0, inf},
+		{5, 0, -1, 1, 7},
+	}, m.Constraints())
+}
+
+func TestReadMPSErrors(t *testing.T) {
+	_, err := ReadMPS(strings.NewReader("ROWS\n N obj\n X bad\n"))
+	assert.Error(t, err)
+
+	_, err = ReadMPS(strings.NewReader("ROWS\n N obj\nCOLUMNS\n    x1 missing 1\n"))
+	assert.Error(t, err)
+
+	_, err = ReadMPS(strings.NewReader("ROWS\n N obj\nCOLUMNS\n    x1 obj one\n"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,236 @@
+package cgc_optimize
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	integralityTol = 1e-6 // distance from an integer accepted as integral
+	mipAbsoluteGap = 1e-9 // objective improvement required to keep exploring a node
+)
+
+func init() {
+	RegisterSolver("native", NativeSolver{})
+}
+
+// NativeSolver is a pure Go solver for builds without cgo. Linear programs are solved with
+// a bounded-variable primal simplex, integer columns by depth first branch and bound.
+// It is sized for microgrid dispatch problems; large models solve faster with HiGHS.
+// A warm start is the starting point of the simplex in SolveLp, and if feasible the first
+// incumbent of a MIP solve.
+type NativeSolver struct{}
+
+func (NativeSolver) SolveLp(w LinearProgram, t_opts SolverOptions) (Result, error) {
+	p, err := newSimplexProblem(w)
+	if err != nil {
+		return Result{Status: StatusError}, err
+	}
+
+	sol, status, err := solveSimplex(p, t_opts.WarmStart, deadline(t_opts))
+	if err != nil {
+		return Result{Status: status}, err
+	}
+
+	return Result{
+		Status:    StatusOptimal,
+		Objective: sol.objective,
+		Columns:   sol.x,
+		Rows:      sol.rows,
+		Duals:     sol.duals,
+	}, nil
+}
+
+func (NativeSolver) SolveMip(w MipLinearProgram, t_opts SolverOptions) (Result, error) {
+	p, err := newSimplexProblem(w)
+	if err != nil {
+		return Result{Status: StatusError}, err
+	}
+
+	intg := w.Integrality()
+	if len(intg) != len(p.cost) && len(intg) != 0 {
+		err := fmt.Sprintf("integrality contains %v columns, expected: %v", len(intg), len(p.cost))
+		return Result{Status: StatusError}, errors.New(err)
+	}
+
+	isInt := make([]bool, len(p.cost))
+	for j, v := range intg {
+		if v != 0 {
+			isInt[j] = true
+			p.lo[j] = math.Ceil(p.lo[j] - integralityTol)
+			p.up[j] = math.Floor(p.up[j] + integralityTol)
+		}
+	}
+
+	bb := branchAndBound{
+		problem:   p,
+		isInt:     isInt,
+		gap:       t_opts.MipGap,
+		deadline:  deadline(t_opts),
+		objective: math.Inf(1),
+	}
+	bb.warmStart(t_opts.WarmStart)
+	return bb.solve()
+}
+
+// deadline returns the wall clock time at which a solve must stop, zero if unlimited.
+func deadline(t_opts SolverOptions) time.Time {
+	if t_opts.TimeLimit <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(t_opts.TimeLimit)
+}
+
+// branchAndBound searches the LP relaxations of a MIP depth first, branching on the
+// most fractional integer column.
+type branchAndBound struct {
+	problem  simplexProblem
+	isInt    []bool
+	gap      float64
+	deadline time.Time
+
+	incumbent simplexSolution
+	objective float64
+	found     bool
+}
+
+// bbNode is a subproblem defined by tightened column bounds
+type bbNode struct {
+	lo []float64
+	up []float64
+}
+
+func (bb *branchAndBound) solve() (Result, error) {
+	stack := []bbNode{{bb.problem.lo, bb.problem.up}}
+	limited := false
+
+	for len(stack) > 0 {
+		if !bb.deadline.IsZero() && time.Now().After(bb.deadline) {
+			limited = true
+			break
+		}
+
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		p := bb.problem
+		p.lo, p.up = node.lo, node.up
+		sol, status, err := solveSimplex(p, nil, bb.deadline)
+		switch {
+		case status == StatusInfeasible:
+			continue
+		case status == StatusUnbounded && !bb.found:
+			return Result{Status: StatusUnbounded}, SolveError{StatusUnbounded}
+		case status == StatusLimit:
+			limited = true
+			stack = stack[:0]
+			continue
+		case err != nil:
+			return Result{Status: status}, err
+		}
+
+		if sol.objective >= bb.objective-bb.tolerance() {
+			continue // bound: relaxation cannot improve on the incumbent
+		}
+
+		j := bb.branchColumn(sol.x)
+		if j < 0 {
+			for k, isInt := range bb.isInt {
+				if isInt {
+					sol.x[k] = math.Round(sol.x[k])
+				}
+			}
+			bb.incumbent, bb.objective, bb.found = sol, sol.objective, true
+			continue
+		}
+
+		v := sol.x[j]
+		down := bbNode{node.lo, append([]float64{}, node.up...)}
+		down.up[j] = math.Floor(v)
+		up := bbNode{append([]float64{}, node.lo...), node.up}
+		up.lo[j] = math.Ceil(v)
+
+		// push the branch nearest the relaxation last so it is explored first
+		if v-math.Floor(v) < 0.5 {
+			stack = append(stack, up, down)
+		} else {
+			stack = append(stack, down, up)
+		}
+	}
+
+	if !bb.found {
+		if limited {
+			return Result{Status: StatusLimit}, SolveError{StatusLimit}
+		}
+		return Result{Status: StatusInfeasible}, SolveError{StatusInfeasible}
+	}
+
+	res := Result{
+		Status:    StatusOptimal,
+		Objective: bb.incumbent.objective,
+		Columns:   bb.incumbent.x,
+		Rows:      bb.incumbent.rows,
+	}
+	if limited {
+		res.Status = StatusLimit
+		return res, SolveError{StatusLimit}
+	}
+	return res, nil
+}
+
+// warmStart makes t_x the incumbent if it is an integral solution satisfying every
+// bound and row of the problem, and ignores it otherwise.
+func (bb *branchAndBound) warmStart(t_x []float64) {
+	p := bb.problem
+	if len(t_x) != len(p.cost) || bb.branchColumn(t_x) >= 0 {
+		return
+	}
+
+	sol := simplexSolution{x: append([]float64{}, t_x...)}
+	for j, v := range sol.x {
+		if bb.isInt[j] {
+			sol.x[j] = math.Round(v)
+		}
+		if sol.x[j] < p.lo[j]-simplexFeasibleTol || sol.x[j] > p.up[j]+simplexFeasibleTol {
+			return
+		}
+		sol.objective += p.cost[j] * sol.x[j]
+	}
+
+	sol.rows = make([]float64, len(p.rows))
+	for i, row := range p.rows {
+		for _, e := range row {
+			sol.rows[i] += e.val * sol.x[e.col]
+		}
+		if sol.rows[i] < p.rowLb[i]-simplexFeasibleTol || sol.rows[i] > p.rowUb[i]+simplexFeasibleTol {
+			return
+		}
+	}
+
+	bb.incumbent, bb.objective, bb.found = sol, sol.objective, true
+}
+
+// tolerance returns the improvement over the incumbent a node must offer to be explored.
+func (bb *branchAndBound) tolerance() float64 {
+	if math.IsInf(bb.objective, 1) {
+		return 0
+	}
+	return math.Max(mipAbsoluteGap, bb.gap*math.Abs(bb.objective))
+}
+
+// branchColumn returns the most fractional integer column of t_x, -1 if t_x is integral.
+func (bb *branchAndBound) branchColumn(t_x []float64) int {
+	best, bestFrac := -1, integralityTol
+	for j, isInt := range bb.isInt {
+		if !isInt {
+			continue
+		}
+		frac := math.Abs(t_x[j] - math.Round(t_x[j]))
+		if frac > bestFrac {
+			best, bestFrac = j, frac
+		}
+	}
+	return best
+}
@@ -0,0 +1,202 @@
+package cgc_optimize_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	opt "github.com/ohowland/cgc_optimize"
+	"github.com/ohowland/cgc_optimize/solvertest"
+	"github.com/stretchr/testify/assert"
+)
+
+// testProgram is a hand written linear program
+type testProgram struct {
+	c    []float64
+	b    [][2]float64
+	a    [][]float64
+	intg []int
+}
+
+func (p testProgram) CostCoefficients() []float64 { return p.c }
+func (p testProgram) Bounds() [][2]float64        { return p.b }
+func (p testProgram) Constraints() [][]float64    { return p.a }
+func (p testProgram) Integrality() []int          { return p.intg }
+
+func TestNativeSolver(t *testing.T) {
+	solvertest.Run(t, opt.NativeSolver{})
+	solvertest.RunMip(t, opt.NativeSolver{})
+}
+
+func TestNativeRegistered(t *testing.T) {
+	s, err := opt.NewSolver("native")
+	assert.Nil(t, err)
+	assert.Equal(t, opt.NativeSolver{}, s)
+}
+
+func TestNativeLpDuals(t *testing.T) {
+	// min x + 2y  s.t.  x + y >= 4,  x <= 3
+	inf := math.Inf(1)
+	p := testProgram{
+		c: []float64{1, 2},
+		b: [][2]float64{{0, 3}, {0, inf}},
+		a: [][]float64{{4, 1, 1, inf}},
+	}
+
+	res, err := opt.NativeSolver{}.SolveLp(p, opt.SolverOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, opt.StatusOptimal, res.Status)
+	assert.InDeltaSlice(t, []float64{3, 1}, res.Columns, 1e-9)
+	assert.InDelta(t, 5, res.Objective, 1e-9)
+	assert.InDeltaSlice(t, []float64{4}, res.Rows, 1e-9)
+	assert.InDeltaSlice(t, []float64{2}, res.Duals, 1e-9)
+}
+
+func TestNativeLpFreeVariables(t *testing.T) {
+	// min x - y  s.t.  x - y >= -3,  x + y == 1,  x, y free
+	inf := math.Inf(1)
+	p := testProgram{
+		c: []float64{1, -1},
+		b: [][2]float64{{-inf, inf}, {-inf, inf}},
+		a: [][]float64{{-3, 1, -1, inf}, {1, 1, 1, 1}},
+	}
+
+	res, err := opt.NativeSolver{}.SolveLp(p, opt.SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDeltaSlice(t, []float64{-1, 2}, res.Columns, 1e-9)
+	assert.InDelta(t, -3, res.Objective, 1e-9)
+}
+
+func TestNativeLpUnbounded(t *testing.T) {
+	inf := math.Inf(1)
+	p := testProgram{
+		c: []float64{-1, 0},
+		b: [][2]float64{{0, inf}, {0, inf}},
+		a: [][]float64{{0, 1, -1, inf}},
+	}
+
+	res, err := opt.NativeSolver{}.SolveLp(p, opt.SolverOptions{})
+	assert.Error(t, err)
+	assert.Equal(t, opt.StatusUnbounded, res.Status)
+}
+
+func TestNativeLpInfeasible(t *testing.T) {
+	p := testProgram{
+		c: []float64{1, 1},
+		b: [][2]float64{{0, 1}, {0, 1}},
+		a: [][]float64{{3, 1, 1, 3}},
+	}
+
+	res, err := opt.NativeSolver{}.SolveLp(p, opt.SolverOptions{})
+	assert.Error(t, err)
+	assert.Equal(t, opt.StatusInfeasible, res.Status)
+}
+
+func TestNativeLpMalformed(t *testing.T) {
+	p := testProgram{
+		c: []float64{1, 1},
+		b: [][2]float64{{0, 1}},
+	}
+
+	res, err := opt.NativeSolver{}.SolveLp(p, opt.SolverOptions{})
+	assert.Error(t, err)
+	assert.Equal(t, opt.StatusError, res.Status)
+}
+
+func TestNativeMipKnapsack(t *testing.T) {
+	// max 5a + 4b + 3c  s.t.  2a + 3b + c <= 5,  4a + b + 2c <= 11,  3a + 4b + 2c <= 8
+	inf := math.Inf(1)
+	p := testProgram{
+		c:    []float64{-5, -4, -3},
+		b:    [][2]float64{{0, inf}, {0, inf}, {0, inf}},
+		a:    [][]float64{{-inf, 2, 3, 1, 5}, {-inf, 4, 1, 2, 11}, {-inf, 3, 4, 2, 8}},
+		intg: []int{1, 1, 1},
+	}
+
+	res, err := opt.NativeSolver{}.SolveMip(p, opt.SolverOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{2, 0, 1}, res.Columns)
+	assert.InDelta(t, -13, res.Objective, 1e-9)
+}
+
+func TestNativeMipBinaryFractionalRelaxation(t *testing.T) {
+	// max x + y  s.t.  2x + 2y <= 3,  x, y binary
+	p := testProgram{
+		c:    []float64{-1, -1},
+		b:    [][2]float64{{0, 1}, {0, 1}},
+		a:    [][]float64{{0, 2, 2, 3}},
+		intg: []int{1, 1},
+	}
+
+	res, err := opt.NativeSolver{}.SolveMip(p, opt.SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, -1, res.Objective, 1e-9)
+	assert.InDelta(t, 1, res.Columns[0]+res.Columns[1], 1e-9)
+}
+
+func TestNativeMipInfeasible(t *testing.T) {
+	// 2x == 1, x integer
+	p := testProgram{
+		c:    []float64{1},
+		b:    [][2]float64{{0, 10}},
+		a:    [][]float64{{1, 2, 1}},
+		intg: []int{1},
+	}
+
+	res, err := opt.NativeSolver{}.SolveMip(p, opt.SolverOptions{TimeLimit: time.Second})
+	assert.Error(t, err)
+	assert.Equal(t, opt.StatusInfeasible, res.Status)
+}
+
+func TestNativeMipWarmStart(t *testing.T) {
+	inf := math.Inf(1)
+	p := testProgram{
+		c:    []float64{-5, -4, -3},
+		b:    [][2]float64{{0, inf}, {0, inf}, {0, inf}},
+		a:    [][]float64{{-inf, 2, 3, 1, 5}, {-inf, 4, 1, 2, 11}, {-inf, 3, 4, 2, 8}},
+		intg: []int{1, 1, 1},
+	}
+
+	for _, ws := range [][]float64{{1, 1, 0}, {2, 0, 1}, {3, 0, 0}, {0.5, 0, 0}, {1}} {
+		res, err := opt.NativeSolver{}.SolveMip(p, opt.SolverOptions{WarmStart: ws})
+		assert.Nil(t, err)
+		assert.Equal(t, []float64{2, 0, 1}, res.Columns)
+		assert.InDelta(t, -13, res.Objective, 1e-9)
+	}
+
+	// an expired time limit returns the warm start
+	res, err := opt.NativeSolver{}.SolveMip(p, opt.SolverOptions{TimeLimit: time.Nanosecond, WarmStart: []float64{1, 1, 0}})
+	assert.Error(t, err)
+	assert.Equal(t, opt.StatusLimit, res.Status)
+	assert.Equal(t, []float64{1, 1, 0}, res.Columns)
+}
+
+func TestNativeLpWarmStart(t *testing.T) {
+	inf := math.Inf(1)
+	p := testProgram{
+		c: []float64{-5, -4, -3},
+		b: [][2]float64{{0, inf}, {0, inf}, {0, inf}},
+		a: [][]float64{{-inf, 2, 3, 1, 5}, {-inf, 4, 1, 2, 11}, {-inf, 3, 4, 2, 8}},
+	}
+
+	cold, err := opt.NativeSolver{}.SolveLp(p, opt.SolverOptions{})
+	assert.Nil(t, err)
+
+	// feasible, interior, infeasible, out of bounds and short warm starts reach the optimum
+	for _, ws := range [][]float64{{2, 0, 1}, {0.5, 0.5, 0.5}, {3, 3, 3}, {-1, 0, inf}, {1}} {
+		res, err := opt.NativeSolver{}.SolveLp(p, opt.SolverOptions{WarmStart: ws})
+		assert.Nil(t, err)
+		assert.Equal(t, opt.StatusOptimal, res.Status)
+		assert.InDelta(t, cold.Objective, res.Objective, 1e-9)
+	}
+
+	// min x - y  s.t.  x - y >= -3,  x + y == 1,  x, y free, started between the bounds
+	q := testProgram{
+		c: []float64{1, -1},
+		b: [][2]float64{{-inf, inf}, {-inf, inf}},
+		a: [][]float64{{-3, 1, -1, inf}, {1, 1, 1, 1}},
+	}
+	res, err := opt.NativeSolver{}.SolveLp(q, opt.SolverOptions{WarmStart: []float64{0.5, 0.5}})
+	assert.Nil(t, err)
+	assert.InDeltaSlice(t, []float64{-1, 2}, res.Columns, 1e-9)
+}
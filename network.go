@@ -0,0 +1,384 @@
+package cgc_optimize
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// FlowModel selects the power flow formulation of the lines of a cluster.
+type FlowModel int
+
+const (
+	// TransportFlow limits the flow of each line by its capacity, less its losses.
+	TransportFlow FlowModel = iota
+	// DCFlow additionally relates the flow of each line to the bus angles of its ends.
+	DCFlow
+)
+
+// LineUnit is one terminal of a line, transformer or closed breaker between two buses.
+// The same line unit is added to the group of each bus it connects, and the terminals
+// are joined with Cluster.NewLine. Power delivered into the bus is real positive power,
+// and power sent from the bus real negative power, so the line takes part in the
+// NetLoadConstraint of each bus.
+//
+// Nothing stops a line from sending power both ways at once. The flows cancel on a
+// lossless line, but a lossy line then burns power, a free sink whenever surplus power
+// has nowhere else to go. EnableDirection forbids it.
+type LineUnit struct {
+	pid          uuid.UUID
+	coefficients []float64
+	bounds       [][2]float64
+	constraints  []SparseConstraint
+	loss         float64
+	susceptance  float64
+	direction    bool
+}
+
+var _ Unit = LineUnit{}
+
+// NewLineUnit returns a configured line unit struct.
+//
+// Cf: Cost coefficient for power sent over the line
+//
+// XfUb: Capacity of the line in each direction
+// Loss: Fraction of sent power lost over the line
+// Susceptance: Flow per unit of bus angle difference, DC flow only
+func NewLineUnit(pid uuid.UUID, Cf float64, XfUb float64, Loss float64, Susceptance float64) LineUnit {
+	coefficients := []float64{0, Cf}
+	bounds := [][2]float64{{0, XfUb}, {0, XfUb}}
+
+	return LineUnit{pid, coefficients, bounds, []SparseConstraint{}, Loss, Susceptance, false}
+}
+
+func (u LineUnit) PID() uuid.UUID {
+	return u.pid
+}
+
+func (u LineUnit) CostCoefficients() []float64 {
+	return u.coefficients
+}
+
+func (u LineUnit) ColumnSize() int {
+	return len(u.coefficients)
+}
+
+// ColumnNames returns the name of each column: xp, xn and, with direction enabled, xd
+func (u LineUnit) ColumnNames() []string {
+	if u.direction {
+		return []string{"xp", "xn", "xd"}
+	}
+	return []string{"xp", "xn"}
+}
+
+// Integrality returns the integer mask of the unit columns. Only the direction column
+// is integer.
+func (u LineUnit) Integrality() []int {
+	mask := make([]int, u.ColumnSize())
+	for _, i := range u.DirectionLoc() {
+		mask[i] = 1
+	}
+	return mask
+}
+
+// EnableDirection adds a binary direction column, Xd, to each terminal of the line, and a
+// constraint that allows power to be sent from the terminal only when it is set:
+//
+// Xn <= XfUb * Xd
+//
+// Cluster.NewLine then allows the direction of only one terminal to be set. The capacity
+// of the line serves as big-M, so it must be finite. The line must then be solved as a
+// MIP, and a series may not raise the capacity with SetUpperBoundProfile. Enabling
+// direction twice has no further effect.
+func (u *LineUnit) EnableDirection() error {
+	if u.direction {
+		return nil
+	}
+
+	xfUb := u.bounds[u.RealNegativePowerLoc()[0]][1]
+	if math.IsInf(xfUb, 1) {
+		err := fmt.Sprintf("direction requires a finite line capacity, found XfUb: %v", xfUb)
+		return errors.New(err)
+	}
+
+	u.direction = true
+	u.coefficients = append(u.coefficients, 0)
+	u.bounds = append(u.bounds, [2]float64{0, 1})
+
+	c := NewSparseConstraint(math.Inf(-1), 0).Named("direction")
+	c.Add(u.RealNegativePowerLoc()[0], 1)
+	c.Add(u.DirectionLoc()[0], -xfUb)
+	u.constraints = append(u.constraints, c)
+	return nil
+}
+
+func (u *LineUnit) NewConstraint(t_c ...[]float64) error {
+	cx, err := validateDense(u.ColumnSize(), t_c)
+	if err != nil {
+		return err
+	}
+
+	// if no errors: add constraints to unit
+	u.constraints = append(u.constraints, cx...)
+	return nil
+}
+
+func (u *LineUnit) NewSparseConstraint(t_c ...SparseConstraint) error {
+	if err := validateSparse(u.ColumnSize(), t_c); err != nil {
+		return err
+	}
+
+	u.constraints = append(u.constraints, t_c...)
+	return nil
+}
+
+func (u LineUnit) Constraints() [][]float64 {
+	return densify(u.ColumnSize(), u.constraints)
+}
+
+func (u LineUnit) SparseConstraints() []SparseConstraint {
+	return u.constraints
+}
+
+func (u LineUnit) Bounds() [][2]float64 {
+	return u.bounds
+}
+
+func (u LineUnit) RealPositivePowerLoc() []int {
+	return []int{0}
+}
+
+func (u LineUnit) RealNegativePowerLoc() []int {
+	return []int{1}
+}
+
+func (u LineUnit) RealCapacityLoc() []int {
+	return []int{}
+}
+
+func (u LineUnit) StoredEnergyLoc() []int {
+	return []int{}
+}
+
+// DirectionLoc returns the location of the direction column, empty unless direction is
+// enabled.
+func (u LineUnit) DirectionLoc() []int {
+	if u.direction {
+		return []int{2}
+	}
+	return []int{}
+}
+
+// directionLimits returns the big-M of the sent power column, empty unless direction is
+// enabled.
+func (u LineUnit) directionLimits() map[int]float64 {
+	m := make(map[int]float64)
+	if u.direction {
+		for _, i := range u.RealNegativePowerLoc() {
+			m[i] = u.bounds[i][1]
+		}
+	}
+	return m
+}
+
+// terminal is the location of a unit within one group of a cluster
+type terminal struct {
+	group int
+	unit  Unit
+	pLoc  []int
+	nLoc  []int
+	dLoc  []int
+}
+
+// terminals returns the location of unit t_pid in each group of the cluster containing it.
+func (cl Cluster) terminals(t_pid uuid.UUID) []terminal {
+	tx := make([]terminal, 0)
+	i := 0
+	for k, g := range cl.groups {
+		j := i
+		for _, u := range g.units {
+			if u.PID() == t_pid {
+				tm := terminal{k, u, []int{}, []int{}, []int{}}
+				for _, p := range u.RealPositivePowerLoc() {
+					tm.pLoc = append(tm.pLoc, p+j)
+				}
+				for _, p := range u.RealNegativePowerLoc() {
+					tm.nLoc = append(tm.nLoc, p+j)
+				}
+				if lu, ok := lineOf(u); ok {
+					for _, p := range lu.DirectionLoc() {
+						tm.dLoc = append(tm.dLoc, p+j)
+					}
+				}
+				tx = append(tx, tm)
+			}
+			j += u.ColumnSize()
+		}
+		i += g.ColumnSize()
+	}
+	return tx
+}
+
+// LinkBus adds the LinkedBusConstraints of unit t_pid to the cluster. It returns an error
+// if the unit is not in exactly two groups, or does not have a single positive and
+// negative power column in each.
+func (cl *Cluster) LinkBus(t_pid uuid.UUID) error {
+	tx := cl.terminals(t_pid)
+	if len(tx) != 2 {
+		err := fmt.Sprintf("linked unit %v is in %v groups, expected: 2", t_pid, len(tx))
+		return errors.New(err)
+	}
+	for _, tm := range tx {
+		if len(tm.pLoc) != 1 || len(tm.nLoc) != 1 {
+			err := fmt.Sprintf("linked unit %v does not have a positive and negative power column", t_pid)
+			return errors.New(err)
+		}
+	}
+
+	return cl.NewSparseConstraint(LinkedBusConstraints(cl, t_pid)...)
+}
+
+// NewLine joins the two terminals of line unit t_pid with the constraints of the flow
+// model t_model. Power sent from one bus is delivered to the other, less losses:
+//
+// Xp_b - (1 - loss) * Xn_a = 0 and Xp_a - (1 - loss) * Xn_b = 0
+//
+// A DC flow line also relates the power sent to the bus angles of its ends:
+//
+// Xn_a - Xn_b - susceptance * (angle_a - angle_b) = 0
+//
+// A bus angle column is added to the cluster for each bus of a DC network, and the angle
+// of one bus of each connected network is fixed to 0 as its reference. A line with
+// direction enabled may send power from only one terminal: Xd_a + Xd_b <= 1. It returns an
+// error if the unit is not a line unit in exactly two groups.
+func (cl *Cluster) NewLine(t_pid uuid.UUID, t_model FlowModel) error {
+	tx := cl.terminals(t_pid)
+	if len(tx) != 2 {
+		err := fmt.Sprintf("line %v is in %v groups, expected: 2", t_pid, len(tx))
+		return errors.New(err)
+	}
+	line, ok := lineOf(tx[0].unit)
+	if !ok {
+		err := fmt.Sprintf("unit %v is not a line unit", t_pid)
+		return errors.New(err)
+	}
+	if tx[0].group == tx[1].group {
+		err := fmt.Sprintf("line %v connects group %v to itself", t_pid, tx[0].group)
+		return errors.New(err)
+	}
+	if line.loss < 0 || line.loss >= 1 {
+		err := fmt.Sprintf("line %v loss outside of [0, 1)", t_pid)
+		return errors.New(err)
+	}
+
+	a, b := tx[0], tx[1]
+	eta := 1 - line.loss
+
+	ab := NewSparseConstraint(0, 0).Named(fmt.Sprintf("%v.line_forward", t_pid))
+	ab.Add(b.pLoc[0], 1)
+	ab.Add(a.nLoc[0], -eta)
+
+	ba := NewSparseConstraint(0, 0).Named(fmt.Sprintf("%v.line_reverse", t_pid))
+	ba.Add(a.pLoc[0], 1)
+	ba.Add(b.nLoc[0], -eta)
+
+	cx := []SparseConstraint{ab, ba}
+	if len(a.dLoc) == 1 && len(b.dLoc) == 1 {
+		d := NewSparseConstraint(math.Inf(-1), 1).Named(fmt.Sprintf("%v.line_direction", t_pid))
+		d.Add(a.dLoc[0], 1)
+		d.Add(b.dLoc[0], 1)
+		cx = append(cx, d)
+	}
+
+	switch t_model {
+	case TransportFlow:
+	case DCFlow:
+		if line.susceptance <= 0 {
+			err := fmt.Sprintf("line %v susceptance is %v, expected a positive value", t_pid, line.susceptance)
+			return errors.New(err)
+		}
+		dc := NewSparseConstraint(0, 0).Named(fmt.Sprintf("%v.dc_flow", t_pid))
+		dc.Add(a.nLoc[0], 1)
+		dc.Add(b.nLoc[0], -1)
+		dc.Add(cl.busAngle(a.group), -line.susceptance)
+		dc.Add(cl.busAngle(b.group), line.susceptance)
+		if err := cl.NewSparseConstraint(append(cx, dc)...); err != nil {
+			return err
+		}
+		cl.joinBuses(a.group, b.group)
+		return nil
+	default:
+		err := fmt.Sprintf("line %v has unknown flow model: %v", t_pid, t_model)
+		return errors.New(err)
+	}
+
+	return cl.NewSparseConstraint(cx...)
+}
+
+// lineOf returns the line unit of u, by value or pointer.
+func lineOf(u Unit) (LineUnit, bool) {
+	switch lu := u.(type) {
+	case LineUnit:
+		return lu, true
+	case *LineUnit:
+		return *lu, true
+	}
+	return LineUnit{}, false
+}
+
+// busAngle returns the bus angle column of group t_k, adding it if needed. A new bus is a
+// DC network of its own, so its angle is fixed to 0 as the reference of the network.
+func (cl *Cluster) busAngle(t_k int) int {
+	if j, ok := cl.angles[t_k]; ok {
+		return j
+	}
+
+	j := cl.NewAuxiliaryColumn(fmt.Sprintf("%vangle", groupPrefix(t_k)), 0, 0, 0)
+	cl.angles = cloneIndex(cl.angles)
+	cl.angles[t_k] = j
+	return j
+}
+
+// joinBuses joins the DC networks of groups t_a and t_b. The reference angle of the
+// network of t_b is freed, so the joined network keeps the single reference of t_a.
+func (cl *Cluster) joinBuses(t_a int, t_b int) {
+	ra, rb := cl.network(t_a), cl.network(t_b)
+	if ra == rb {
+		return
+	}
+
+	cl.networks = cloneIndex(cl.networks)
+	cl.networks[rb] = ra
+
+	i := cl.auxIndex(cl.angles[rb])
+	cl.aux = append([]auxColumn{}, cl.aux...)
+	cl.aux[i].bounds = [2]float64{math.Inf(-1), math.Inf(1)}
+}
+
+// auxIndex returns the index within the auxiliary columns of the cluster of column t_j.
+func (cl Cluster) auxIndex(t_j int) int {
+	return t_j - (cl.ColumnSize() - len(cl.aux))
+}
+
+// cloneIndex returns a copy of t_m. The bus maps are copied before each write, so copies
+// of a cluster do not share the lines joined after they were copied.
+func cloneIndex(t_m map[int]int) map[int]int {
+	m := make(map[int]int, len(t_m))
+	for k, v := range t_m {
+		m[k] = v
+	}
+	return m
+}
+
+// network returns the group whose angle is the reference of the DC network of group t_k.
+func (cl Cluster) network(t_k int) int {
+	for {
+		r, ok := cl.networks[t_k]
+		if !ok || r == t_k {
+			return t_k
+		}
+		t_k = r
+	}
+}
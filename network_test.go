@@ -0,0 +1,233 @@
+package cgc_optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClusterLinkBus(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	pid2, _ := uuid.NewUUID()
+	a1 := NewBasicUnit(pid1, 1, 0, 0, 0, 10, 10, 0, 0)
+	a2 := NewBasicUnit(pid2, 1, 0, 0, 0, 10, 10, 0, 0)
+
+	cl := NewCluster(NewGroup(a1, a2), NewGroup(a1))
+	err := cl.LinkBus(pid1)
+	assert.Nil(t, err)
+	assert.Len(t, cl.SparseConstraints(), 2)
+
+	err = cl.LinkBus(pid2)
+	assert.Error(t, err, "link of unit in one group accepted")
+
+	pid3, _ := uuid.NewUUID()
+	err = cl.LinkBus(pid3)
+	assert.Error(t, err, "link of unknown unit accepted")
+	assert.Len(t, cl.SparseConstraints(), 2)
+}
+
+func TestClusterTransportLine(t *testing.T) {
+	inf := math.Inf(1)
+	cheapPID, _ := uuid.NewUUID()
+	dearPID, _ := uuid.NewUUID()
+	linePID, _ := uuid.NewUUID()
+	line := NewLineUnit(linePID, 0, 6, 0.1, 0)
+
+	ga := NewGroup(NewBasicUnit(cheapPID, 1, 0, 0, 0, inf, 0, 0, 0), line)
+	err := ga.NewSparseConstraint(NetLoadConstraint(&ga, 0))
+	assert.Nil(t, err)
+	gb := NewGroup(NewBasicUnit(dearPID, 5, 0, 0, 0, inf, 0, 0, 0), line)
+	err = gb.NewSparseConstraint(NetLoadConstraint(&gb, 10))
+	assert.Nil(t, err)
+
+	cl := NewCluster(ga, gb)
+	err = cl.NewLine(linePID, TransportFlow)
+	assert.Nil(t, err)
+	names := cl.ConstraintNames()
+	assert.Contains(t, names, linePID.String()+".line_forward")
+	assert.Contains(t, names, linePID.String()+".line_reverse")
+
+	res, err := NativeSolver{}.SolveLp(cl, SolverOptions{})
+	assert.Nil(t, err)
+	sr, err := cl.Decode(res.Columns)
+	assert.Nil(t, err)
+
+	// the line sends its capacity of 6 from the cheap bus, delivering 5.4
+	assert.InDelta(t, 6, sr[cheapPID].RealPositivePower, 1e-6)
+	assert.InDelta(t, 4.6, sr[dearPID].RealPositivePower, 1e-6)
+	assert.InDelta(t, 6+5*4.6, res.Objective, 1e-6)
+}
+
+func TestClusterDCFlow(t *testing.T) {
+	inf := math.Inf(1)
+	genPID, _ := uuid.NewUUID()
+	l01, _ := uuid.NewUUID()
+	l12, _ := uuid.NewUUID()
+	l02, _ := uuid.NewUUID()
+	line01 := NewLineUnit(l01, 0, inf, 0, 1)
+	line12 := NewLineUnit(l12, 0, inf, 0, 1)
+	line02 := NewLineUnit(l02, 0, inf, 0, 1)
+
+	g0 := NewGroup(NewBasicUnit(genPID, 1, 0, 0, 0, inf, 0, 0, 0), line01, line02)
+	g1 := NewGroup(line01, line12)
+	g2 := NewGroup(line12, line02)
+	for k, nl := range []float64{0, 0, 9} {
+		g := []*Group{&g0, &g1, &g2}[k]
+		err := g.NewSparseConstraint(NetLoadConstraint(g, nl))
+		assert.Nil(t, err)
+	}
+
+	cl := NewCluster(g0, g1, g2)
+	size := cl.ColumnSize()
+	for _, pid := range []uuid.UUID{l01, l12, l02} {
+		err := cl.NewLine(pid, DCFlow)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, size+3, cl.ColumnSize())
+	assert.Equal(t, "g2.angle", cl.ColumnNames()[size+2])
+	assert.Len(t, cl.Integrality(), size+3)
+
+	// the first bus is the reference of the network
+	assert.Equal(t, [2]float64{0, 0}, cl.Bounds()[size])
+	assert.Equal(t, [2]float64{-inf, inf}, cl.Bounds()[size+1])
+	assert.Equal(t, [2]float64{-inf, inf}, cl.Bounds()[size+2])
+
+	res, err := NativeSolver{}.SolveLp(cl, SolverOptions{})
+	assert.Nil(t, err)
+
+	// equal susceptances: two thirds of the load flows over the direct line
+	x := res.Columns
+	flow := func(pid uuid.UUID) float64 {
+		n := cl.RealNegativePowerPidLoc(pid)
+		return x[n[0]] - x[n[1]]
+	}
+	assert.InDelta(t, 6, flow(l02), 1e-6)
+	assert.InDelta(t, 3, flow(l01), 1e-6)
+	assert.InDelta(t, 3, flow(l12), 1e-6)
+}
+
+func TestClusterDCFlowReference(t *testing.T) {
+	inf := math.Inf(1)
+	l01, _ := uuid.NewUUID()
+	l23, _ := uuid.NewUUID()
+	l12, _ := uuid.NewUUID()
+	line01 := NewLineUnit(l01, 0, inf, 0, 1)
+	line23 := NewLineUnit(l23, 0, inf, 0, 1)
+	line12 := NewLineUnit(l12, 0, inf, 0, 1)
+
+	cl := NewCluster(NewGroup(line01), NewGroup(line01, line12), NewGroup(line12, line23), NewGroup(line23))
+	size := cl.ColumnSize()
+
+	// two separate networks each have a reference
+	assert.Nil(t, cl.NewLine(l01, DCFlow))
+	assert.Nil(t, cl.NewLine(l23, DCFlow))
+	fixed := func() int {
+		n := 0
+		for _, b := range cl.Bounds()[size:] {
+			if b == [2]float64{0, 0} {
+				n++
+			}
+		}
+		return n
+	}
+	assert.Equal(t, 2, fixed())
+
+	// joining them leaves one, and a copy made before the join keeps both
+	orig := cl
+	assert.Nil(t, cl.NewLine(l12, DCFlow))
+	assert.Equal(t, 1, fixed())
+	assert.Equal(t, [2]float64{0, 0}, cl.Bounds()[size])
+	cl = orig
+	assert.Equal(t, 2, fixed())
+	assert.Equal(t, map[int]int{1: 0, 3: 2}, orig.networks)
+}
+
+func TestClusterDCFlowCopies(t *testing.T) {
+	inf := math.Inf(1)
+	l1, _ := uuid.NewUUID()
+	l2, _ := uuid.NewUUID()
+	line1 := NewLineUnit(l1, 0, inf, 0, 1)
+	line2 := NewLineUnit(l2, 0, inf, 0, 1)
+
+	base := NewCluster(NewGroup(line1, line2), NewGroup(line1), NewGroup(line2))
+	size := base.ColumnSize()
+
+	// lines added to copies of a cluster do not reach the other copies
+	a, b := base, base
+	assert.Nil(t, a.NewLine(l1, DCFlow))
+	assert.Empty(t, base.angles)
+	assert.Empty(t, b.angles)
+	assert.Nil(t, b.NewLine(l2, DCFlow))
+	assert.Equal(t, map[int]int{0: size, 1: size + 1}, a.angles)
+	assert.Equal(t, map[int]int{0: size, 2: size + 1}, b.angles)
+
+	// joining the networks of a copy leaves both references of the original fixed
+	c := b
+	assert.Nil(t, c.NewLine(l1, DCFlow))
+	assert.Equal(t, [2]float64{0, 0}, b.Bounds()[size])
+	assert.Equal(t, [2]float64{-inf, inf}, b.Bounds()[size+1])
+	assert.Equal(t, [2]float64{-inf, inf}, c.Bounds()[size+2])
+	assert.Len(t, b.aux, 2)
+	assert.Len(t, c.aux, 3)
+
+	d := a
+	assert.Nil(t, d.NewLine(l2, DCFlow))
+	assert.Equal(t, [2]float64{-inf, inf}, d.Bounds()[size+1])
+	assert.Equal(t, [2]float64{-inf, inf}, d.Bounds()[size+2])
+	assert.Equal(t, [2]float64{-inf, inf}, a.Bounds()[size+1])
+	assert.Len(t, a.aux, 2)
+}
+
+func TestLineUnitEnableDirection(t *testing.T) {
+	pid, _ := uuid.NewUUID()
+	line := NewLineUnit(pid, 0, 100, 0.1, 0)
+	err := line.EnableDirection()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"xp", "xn", "xd"}, line.ColumnNames())
+	assert.Equal(t, []int{0, 0, 1}, line.Integrality())
+	assert.Equal(t, [][]float64{{math.Inf(-1), 0, 1, -100, 0}}, line.Constraints())
+
+	unlimited := NewLineUnit(pid, 0, math.Inf(1), 0.1, 0)
+	assert.Error(t, unlimited.EnableDirection())
+
+	// a bus with surplus power and nowhere else to send it
+	newCluster := func(u LineUnit) Cluster {
+		g0, g1 := NewGroup(u), NewGroup(u)
+		assert.Nil(t, g0.NewSparseConstraint(NetLoadConstraint(&g0, -5)))
+		assert.Nil(t, g1.NewSparseConstraint(NetLoadConstraint(&g1, 0)))
+		cl := NewCluster(g0, g1)
+		assert.Nil(t, cl.NewLine(pid, TransportFlow))
+		return cl
+	}
+
+	// a lossy line burns the surplus by sending power both ways
+	_, err = NativeSolver{}.SolveLp(newCluster(NewLineUnit(pid, 0, 100, 0.1, 0)), SolverOptions{})
+	assert.Nil(t, err)
+
+	cl := newCluster(line)
+	assert.Contains(t, cl.ConstraintNames(), pid.String()+".line_direction")
+	_, err = NativeSolver{}.SolveMip(cl, SolverOptions{})
+	assert.Error(t, err, "line sent power both ways with direction enabled")
+}
+
+func TestClusterNewLineErrors(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	linePID, _ := uuid.NewUUID()
+	a1 := NewBasicUnit(pid1, 1, 0, 0, 0, 10, 10, 0, 0)
+	line := NewLineUnit(linePID, 0, 10, 0, 0)
+
+	cl := NewCluster(NewGroup(a1, line), NewGroup(a1, line))
+	assert.Error(t, cl.NewLine(pid1, TransportFlow), "line of basic unit accepted")
+	assert.Error(t, cl.NewLine(linePID, DCFlow), "dc line without susceptance accepted")
+	assert.Error(t, cl.NewLine(linePID, FlowModel(7)), "unknown flow model accepted")
+
+	cl = NewCluster(NewGroup(line, line))
+	assert.Error(t, cl.NewLine(linePID, TransportFlow), "line within one group accepted")
+
+	lossy := NewLineUnit(linePID, 0, 10, 1, 0)
+	cl = NewCluster(NewGroup(&lossy), NewGroup(&lossy))
+	assert.Error(t, cl.NewLine(linePID, TransportFlow), "line losing all power accepted")
+	assert.Len(t, cl.SparseConstraints(), 0)
+}
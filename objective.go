@@ -0,0 +1,283 @@
+package cgc_optimize
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+// ObjectiveTerm is a named linear expression over the columns of a program, such as fuel
+// cost, emissions, degradation or grid cost: Sum_k(Value_k * x_Index_k)
+type ObjectiveTerm struct {
+	Name  string
+	Index []int
+	Value []float64
+	pos   map[int]int // position of each column in Index
+}
+
+// NewObjectiveTerm returns a named term with no coefficients.
+func NewObjectiveTerm(t_name string) ObjectiveTerm {
+	return ObjectiveTerm{t_name, []int{}, []float64{}, map[int]int{}}
+}
+
+// Add adds t_val to the coefficient of column t_col.
+func (o *ObjectiveTerm) Add(t_col int, t_val float64) {
+	if len(o.pos) != len(o.Index) {
+		o.pos = make(map[int]int, len(o.Index))
+		for k, i := range o.Index {
+			o.pos[i] = k
+		}
+	}
+	if k, ok := o.pos[t_col]; ok {
+		o.Value[k] += t_val
+		return
+	}
+	o.pos[t_col] = len(o.Index)
+	o.Index = append(o.Index, t_col)
+	o.Value = append(o.Value, t_val)
+}
+
+// Evaluate returns the value of the term at solution t_x.
+func (o ObjectiveTerm) Evaluate(t_x []float64) float64 {
+	var v float64
+	for k, i := range o.Index {
+		v += o.Value[k] * t_x[i]
+	}
+	return v
+}
+
+// unitCoster is implemented by groups, clusters and series
+type unitCoster interface {
+	CostCoefficients() []float64
+	UnitLoc
+}
+
+// CostTerm returns the cost coefficients of the units t_pids of t_w as a term, or of every
+// column if no units are given. Auxiliary columns owned by a unit, such as the peak import
+// of a demand charge, are part of the cost of the unit.
+//
+// Fuel and grid cost are the CostTerm of the generator and grid units; emissions and
+// degradation are given by EmissionsTerm and DegradationTerm.
+func CostTerm(t_name string, t_w unitCoster, t_pids ...uuid.UUID) ObjectiveTerm {
+	cc := t_w.CostCoefficients()
+	o := NewObjectiveTerm(t_name)
+	if len(t_pids) == 0 {
+		for j, c := range cc {
+			if c != 0 {
+				o.Add(j, c)
+			}
+		}
+		return o
+	}
+
+	for _, pid := range t_pids {
+		loc := t_w.PidLoc(pid, allLoc)
+		if al, ok := t_w.(auxiliaryLocator); ok {
+			loc = append(loc, al.AuxiliaryPidLoc(pid)...)
+		}
+		for _, j := range loc {
+			if cc[j] != 0 {
+				o.Add(j, cc[j])
+			}
+		}
+	}
+	return o
+}
+
+// auxiliaryLocator is implemented by programs with auxiliary columns owned by units
+type auxiliaryLocator interface {
+	AuxiliaryPidLoc(uuid.UUID) []int
+}
+
+// LocTerm returns a term with coefficient t_c on each column of t_loc.
+func LocTerm(t_name string, t_loc []int, t_c float64) ObjectiveTerm {
+	o := NewObjectiveTerm(t_name)
+	for _, j := range t_loc {
+		o.Add(j, t_c)
+	}
+	return o
+}
+
+// allLoc selects every column of u.
+func allLoc(u Unit) []int {
+	loc := make([]int, u.ColumnSize())
+	for j := range loc {
+		loc[j] = j
+	}
+	return loc
+}
+
+// objectiveEntry is a term of an objective with its weight and priority
+type objectiveEntry struct {
+	term     ObjectiveTerm
+	weight   float64
+	priority int
+}
+
+// Objective replaces the cost coefficients of a program with weighted objective terms.
+// It is itself a program: the cost of each column is the weighted sum of the terms, so
+// solving it directly optimizes the weighted objective. SolveLexicographic instead
+// optimizes the terms in priority order.
+type Objective struct {
+	program MipLinearProgram
+	terms   []objectiveEntry
+}
+
+var _ MipLinearProgram = Objective{}
+var _ SparseLinearProgram = Objective{}
+
+// NewObjective returns an objective over the columns of t_w with no terms.
+func NewObjective(t_w MipLinearProgram) Objective {
+	return Objective{t_w, []objectiveEntry{}}
+}
+
+// AddTerm adds t_term to the objective, scaled by t_weight. Terms with a lower t_priority
+// are optimized first by SolveLexicographic. It returns an error if the term references a
+// column outside of the program.
+func (o *Objective) AddTerm(t_term ObjectiveTerm, t_weight float64, t_priority int) error {
+	n := len(o.program.CostCoefficients())
+	for _, j := range t_term.Index {
+		if j < 0 || j >= n {
+			err := fmt.Sprintf("objective term %v column %v out of range, expected: [0, %v)", t_term.Name, j, n)
+			return errors.New(err)
+		}
+	}
+
+	o.terms = append(o.terms, objectiveEntry{t_term, t_weight, t_priority})
+	return nil
+}
+
+// Evaluate returns the unweighted value of each term at solution t_x, by term name.
+func (o Objective) Evaluate(t_x []float64) map[string]float64 {
+	v := make(map[string]float64)
+	for _, e := range o.terms {
+		v[e.term.Name] += e.term.Evaluate(t_x)
+	}
+	return v
+}
+
+// CostCoefficients returns the weighted sum of every term.
+func (o Objective) CostCoefficients() []float64 {
+	return o.weighted(func(int) bool { return true })
+}
+
+// weighted returns the weighted sum of the terms with a priority selected by t_sel.
+func (o Objective) weighted(t_sel func(int) bool) []float64 {
+	cc := make([]float64, len(o.program.CostCoefficients()))
+	for _, e := range o.terms {
+		if !t_sel(e.priority) {
+			continue
+		}
+		for k, j := range e.term.Index {
+			cc[j] += e.weight * e.term.Value[k]
+		}
+	}
+	return cc
+}
+
+func (o Objective) Bounds() [][2]float64 {
+	return o.program.Bounds()
+}
+
+func (o Objective) Constraints() [][]float64 {
+	return o.program.Constraints()
+}
+
+func (o Objective) SparseConstraints() []SparseConstraint {
+	return SparseConstraintsOf(o.program)
+}
+
+func (o Objective) Integrality() []int {
+	return o.program.Integrality()
+}
+
+// ColumnNames returns the column names of the program, nil if it does not name them.
+func (o Objective) ColumnNames() []string {
+	if nw, ok := o.program.(columnNamer); ok {
+		return nw.ColumnNames()
+	}
+	return nil
+}
+
+// priorities returns the distinct priorities of the terms in ascending order.
+func (o Objective) priorities() []int {
+	seen := make(map[int]bool)
+	px := make([]int, 0)
+	for _, e := range o.terms {
+		if !seen[e.priority] {
+			seen[e.priority] = true
+			px = append(px, e.priority)
+		}
+	}
+	sort.Ints(px)
+	return px
+}
+
+// SolveLexicographic optimizes the weighted terms of each priority in ascending order.
+// After each solve the optimum f of the priority is pinned with a constraint of the form:
+// Sum_i(w_i * term_i) <= f + t_tol * max(1, |f|), so later priorities only choose among
+// solutions that are optimal, within tolerance, for every earlier one. Each solve is warm
+// started with the previous solution.
+//
+// The result is that of the final solve; its rows are followed by the pinned rows, named
+// "objective.p<priority>".
+func SolveLexicographic(t_s Solver, t_o Objective, t_opts SolverOptions, t_tol float64) (Result, error) {
+	px := t_o.priorities()
+	if len(px) == 0 {
+		return Result{Status: StatusError}, errors.New("objective has no terms")
+	}
+
+	lp := pinnedProgram{t_o, nil, []SparseConstraint{}}
+	var res Result
+	for k, p := range px {
+		p := p
+		lp.cost = t_o.weighted(func(q int) bool { return q == p })
+
+		var err error
+		if isMip(lp.Integrality()) {
+			res, err = t_s.SolveMip(lp, t_opts)
+		} else {
+			res, err = t_s.SolveLp(lp, t_opts)
+		}
+		if err != nil {
+			return res, err
+		}
+		if k == len(px)-1 {
+			break
+		}
+
+		f := ObjectiveValue(lp, res.Columns)
+		c := NewSparseConstraint(math.Inf(-1), f+t_tol*math.Max(1, math.Abs(f))).Named(fmt.Sprintf("objective.p%v", p))
+		for j, v := range lp.cost {
+			if v != 0 {
+				c.Add(j, v)
+			}
+		}
+		lp.pins = append(lp.pins, c)
+		t_opts.WarmStart = res.Columns
+	}
+	return res, nil
+}
+
+// pinnedProgram is an objective program optimizing the cost of one priority, subject to
+// the pinned optima of the earlier priorities
+type pinnedProgram struct {
+	Objective
+	cost []float64
+	pins []SparseConstraint
+}
+
+func (lp pinnedProgram) CostCoefficients() []float64 {
+	return lp.cost
+}
+
+func (lp pinnedProgram) Constraints() [][]float64 {
+	return densify(len(lp.cost), lp.SparseConstraints())
+}
+
+func (lp pinnedProgram) SparseConstraints() []SparseConstraint {
+	return append(append([]SparseConstraint{}, lp.Objective.SparseConstraints()...), lp.pins...)
+}
@@ -0,0 +1,122 @@
+package cgc_optimize
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func NewTestObjectiveGroup(t_nl float64) (Group, []uuid.UUID) {
+	pidA, _ := uuid.NewUUID()
+	pidB, _ := uuid.NewUUID()
+	pidC, _ := uuid.NewUUID()
+	a := NewBasicUnit(pidA, 1, 0, 0, 0, 10, 0, 0, 0)
+	b := NewBasicUnit(pidB, 1, 0, 0, 0, 10, 0, 0, 0)
+	c := NewBasicUnit(pidC, 2, 0, 0, 0, 10, 0, 0, 0)
+	g := NewGroup(a, b, c)
+	g.NewSparseConstraint(NetLoadConstraint(&g, t_nl))
+	return g, []uuid.UUID{pidA, pidB, pidC}
+}
+
+func TestObjectiveTerm(t *testing.T) {
+	o := NewObjectiveTerm("test")
+	o.Add(2, 1)
+	o.Add(0, 3)
+	o.Add(2, 0.5)
+
+	assert.Equal(t, "test", o.Name)
+	assert.Equal(t, []int{2, 0}, o.Index)
+	assert.Equal(t, []float64{1.5, 3}, o.Value)
+	assert.Equal(t, 3*2+1.5*4, o.Evaluate([]float64{2, 5, 4}))
+
+	g, pids := NewTestObjectiveGroup(0)
+	assert.Equal(t, g.RealPositivePowerPidLoc(pids[2]), CostTerm("fuel", &g, pids[2]).Index)
+	assert.Equal(t, []float64{2}, CostTerm("fuel", &g, pids[2]).Value)
+	assert.Len(t, CostTerm("fuel", &g).Index, 3)
+
+	l := LocTerm("emissions", []int{1, 4}, 0.5)
+	assert.Equal(t, []int{1, 4}, l.Index)
+	assert.Equal(t, []float64{0.5, 0.5}, l.Value)
+}
+
+func TestObjectiveWeighted(t *testing.T) {
+	g, pids := NewTestObjectiveGroup(15)
+	fuel := CostTerm("fuel", &g)
+	emissions := LocTerm("emissions", g.RealPositivePowerPidLoc(pids[0]), 1)
+	emissions.Add(g.RealPositivePowerPidLoc(pids[2])[0], 0.1)
+
+	o := NewObjective(&g)
+	assert.Nil(t, o.AddTerm(fuel, 1, 0))
+	assert.Nil(t, o.AddTerm(emissions, 10, 0))
+	assert.Equal(t, g.Bounds(), o.Bounds())
+	assert.Equal(t, g.Constraints(), o.Constraints())
+	assert.Equal(t, g.ColumnNames(), o.ColumnNames())
+
+	cc := o.CostCoefficients()
+	assert.Equal(t, 11.0, cc[g.RealPositivePowerPidLoc(pids[0])[0]])
+	assert.Equal(t, 1.0, cc[g.RealPositivePowerPidLoc(pids[1])[0]])
+	assert.Equal(t, 3.0, cc[g.RealPositivePowerPidLoc(pids[2])[0]])
+
+	res, err := NativeSolver{}.SolveLp(o, SolverOptions{})
+	assert.Nil(t, err)
+	sr, err := g.Decode(res.Columns)
+	assert.Nil(t, err)
+	assert.InDelta(t, 0, sr[pids[0]].RealPositivePower, 1e-6)
+	assert.InDelta(t, 10, sr[pids[1]].RealPositivePower, 1e-6)
+	assert.InDelta(t, 5, sr[pids[2]].RealPositivePower, 1e-6)
+
+	v := o.Evaluate(res.Columns)
+	assert.InDelta(t, 20, v["fuel"], 1e-6)
+	assert.InDelta(t, 0.5, v["emissions"], 1e-6)
+
+	err = o.AddTerm(LocTerm("bad", []int{g.ColumnSize()}, 1), 1, 0)
+	assert.Error(t, err, "term out of range accepted")
+}
+
+func TestSolveLexicographic(t *testing.T) {
+	g, pids := NewTestObjectiveGroup(15)
+	fuel := CostTerm("fuel", &g)
+	emissions := LocTerm("emissions", g.RealPositivePowerPidLoc(pids[0]), 1)
+
+	// cost first: A and B tie on cost, emissions then prefer B
+	o := NewObjective(&g)
+	assert.Nil(t, o.AddTerm(fuel, 1, 0))
+	assert.Nil(t, o.AddTerm(emissions, 1, 1))
+
+	res, err := SolveLexicographic(NativeSolver{}, o, SolverOptions{}, 1e-9)
+	assert.Nil(t, err)
+	v := o.Evaluate(res.Columns)
+	assert.InDelta(t, 15, v["fuel"], 1e-6)
+	assert.InDelta(t, 5, v["emissions"], 1e-6)
+	assert.Len(t, res.Rows, len(g.SparseConstraints())+1)
+
+	// emissions first: A is excluded, cost then prefers B over C
+	o = NewObjective(&g)
+	assert.Nil(t, o.AddTerm(fuel, 1, 1))
+	assert.Nil(t, o.AddTerm(emissions, 1, -1))
+
+	res, err = SolveLexicographic(NativeSolver{}, o, SolverOptions{}, 1e-9)
+	assert.Nil(t, err)
+	sr, err := g.Decode(res.Columns)
+	assert.Nil(t, err)
+	assert.InDelta(t, 0, sr[pids[0]].RealPositivePower, 1e-6)
+	assert.InDelta(t, 10, sr[pids[1]].RealPositivePower, 1e-6)
+	assert.InDelta(t, 5, sr[pids[2]].RealPositivePower, 1e-6)
+	assert.InDelta(t, 20, res.Objective, 1e-6)
+
+	_, err = SolveLexicographic(NativeSolver{}, NewObjective(&g), SolverOptions{}, 0)
+	assert.Error(t, err, "objective without terms solved")
+}
+
+func TestSolveLexicographicMip(t *testing.T) {
+	s := NewTestModelSeries()
+	o := NewObjective(&s)
+	assert.Nil(t, o.AddTerm(CostTerm("cost", &s), 1, 0))
+
+	want, err := NativeSolver{}.SolveMip(&s, SolverOptions{})
+	assert.Nil(t, err)
+	res, err := SolveLexicographic(NativeSolver{}, o, SolverOptions{}, 0)
+	assert.Nil(t, err)
+	assert.InDelta(t, want.Objective, res.Objective, 1e-6)
+}
@@ -1,7 +1,6 @@
 package cgc_optimize
 
 import (
-	"errors"
 	"fmt"
 	"math"
 
@@ -12,16 +11,23 @@ type PiecewiseUnit struct {
 	pid            uuid.UUID
 	coefficients   []float64
 	bounds         [][2]float64
-	constraints    [][]float64
+	constraints    []SparseConstraint
 	binaries       []int
 	criticalPoints []CriticalPoint
 }
 
+var _ Unit = PiecewiseUnit{}
+
 type CriticalPoint struct {
 	val  float64
 	cost float64
 }
 
+// NewCriticalPoint returns a point of a piecewise cost curve: t_val with cost t_cost
+func NewCriticalPoint(t_val float64, t_cost float64) CriticalPoint {
+	return CriticalPoint{t_val, t_cost}
+}
+
 // NewPiecewiseUnit returns a configured unit struct.
 //
 // XpUb: Upper bound for real positive power decision variable
@@ -60,22 +66,41 @@ func NewPiecewiseUnit(pid uuid.UUID, C []CriticalPoint) PiecewiseUnit {
 		binaryIndex[i] = 1
 	}
 
-	// create segment constraints, this is a diagonal matrix
-	constraints := [][]float64{}
+	// a single critical point has no segment binaries, so its column is a single linear
+	// segment from 0 bounded by the point and needs no segment constraints
+	constraints := []SparseConstraint{}
+	if len(C) < 2 {
+		return PiecewiseUnit{pid, coefficients, bounds, constraints, binaryIndex, C}
+	}
+
+	// create segment constraints, this is a diagonal matrix: |x_i| - |val_i|*(b_(i-1) + b_i) <= 0
+	// so only the critical points bounding an active segment may be nonzero
 	for i := range C {
-		constraint := make([]float64, len(coefficients))
-		constraint[i] = 1
+		constraint := NewSparseConstraint(math.Inf(-1), 0).Named(fmt.Sprintf("segment%v", i))
+		if C[i].val >= 0 {
+			constraint.Add(i, 1)
+		} else {
+			constraint.Add(i, -1)
+		}
 
+		m := math.Abs(C[i].val)
 		if i < len(C)-1 {
-			constraint[i+len(C)] = -1
+			constraint.Add(i+len(C), -m)
 		}
 		if i > 0 {
-			constraint[i+len(C)-1] = -1
+			constraint.Add(i+len(C)-1, -m)
 		}
 
 		constraints = append(constraints, constraint)
 	}
 
+	// exactly one segment is active: Sum_i(b_i) = 1
+	selection := NewSparseConstraint(1, 1).Named("segment_select")
+	for i := len(C); i < len(coefficients); i++ {
+		selection.Add(i, 1)
+	}
+	constraints = append(constraints, selection)
+
 	return PiecewiseUnit{pid, coefficients, bounds, constraints, binaryIndex, C}
 }
 
@@ -91,14 +116,23 @@ func (u PiecewiseUnit) ColumnSize() int {
 	return len(u.coefficients)
 }
 
+// ColumnNames returns the name of each column: a segment per critical point followed by
+// the segment binaries
+func (u PiecewiseUnit) ColumnNames() []string {
+	names := make([]string, 0)
+	for i := range u.criticalPoints {
+		names = append(names, fmt.Sprintf("seg%v", i))
+	}
+	for i := len(u.criticalPoints); i < u.ColumnSize(); i++ {
+		names = append(names, fmt.Sprintf("bin%v", i-len(u.criticalPoints)))
+	}
+	return names
+}
+
 func (u *PiecewiseUnit) NewConstraint(t_c ...[]float64) error {
-	cx := make([][]float64, 0)
-	for _, c := range t_c {
-		if len(c) != u.ColumnSize()+2 {
-			err := fmt.Sprintf("constraint contains %v columns, expected: %v", len(c), u.ColumnSize()+2)
-			return errors.New(err)
-		}
-		cx = append(cx, c)
+	cx, err := validateDense(u.ColumnSize(), t_c)
+	if err != nil {
+		return err
 	}
 
 	// if no errors: add constraints to unit
@@ -106,7 +140,20 @@ func (u *PiecewiseUnit) NewConstraint(t_c ...[]float64) error {
 	return nil
 }
 
+func (u *PiecewiseUnit) NewSparseConstraint(t_c ...SparseConstraint) error {
+	if err := validateSparse(u.ColumnSize(), t_c); err != nil {
+		return err
+	}
+
+	u.constraints = append(u.constraints, t_c...)
+	return nil
+}
+
 func (u PiecewiseUnit) Constraints() [][]float64 {
+	return densify(u.ColumnSize(), u.constraints)
+}
+
+func (u PiecewiseUnit) SparseConstraints() []SparseConstraint {
 	return u.constraints
 }
 
@@ -114,6 +161,11 @@ func (u PiecewiseUnit) Bounds() [][2]float64 {
 	return u.bounds
 }
 
+// Integrality returns the integer mask of the unit columns: the segment binaries.
+func (u PiecewiseUnit) Integrality() []int {
+	return u.binaries
+}
+
 func (u PiecewiseUnit) RealPositivePowerLoc() []int {
 	return []int{0}
 }
@@ -126,35 +178,35 @@ func (u PiecewiseUnit) RealCapacityLoc() []int {
 	return []int{2}
 }
 
+func (u PiecewiseUnit) StoredEnergyLoc() []int {
+	return []int{}
+}
+
 // Constraints
 
-func PiecewiseUnitCapacityConstraints(u *PiecewiseUnit) [][]float64 {
-	cx := make([][]float64, 0)
+func PiecewiseUnitCapacityConstraints(u *PiecewiseUnit) []SparseConstraint {
+	cx := make([]SparseConstraint, 0)
 	cx = append(cx, PiecewiseUnitPositiveCapacityConstraint(u))
 	cx = append(cx, PiecewiseUnitNegativeCapacityConstraint(u))
 	return cx
 }
 
-func PiecewiseUnitPositiveCapacityConstraint(u *PiecewiseUnit) []float64 {
+func PiecewiseUnitPositiveCapacityConstraint(u *PiecewiseUnit) SparseConstraint {
 	xp := u.RealPositivePowerLoc()[0]
 	xc := u.RealCapacityLoc()[0]
 
-	cp := make([]float64, u.ColumnSize())
-	cp[xp] = -1
-	cp[xc] = 1
-
-	cp = boundConstraint(cp, 0, math.Inf(1))
+	cp := NewSparseConstraint(0, math.Inf(1)).Named("positive_capacity")
+	cp.Add(xp, -1)
+	cp.Add(xc, 1)
 	return cp
 }
 
-func PiecewiseUnitNegativeCapacityConstraint(u *PiecewiseUnit) []float64 {
+func PiecewiseUnitNegativeCapacityConstraint(u *PiecewiseUnit) SparseConstraint {
 	xn := u.RealNegativePowerLoc()[0]
 	xc := u.RealCapacityLoc()[0]
 
-	cn := make([]float64, u.ColumnSize())
-	cn[xn] = -1
-	cn[xc] = 1
-
-	cn = boundConstraint(cn, 0, math.Inf(1))
+	cn := NewSparseConstraint(0, math.Inf(1)).Named("negative_capacity")
+	cn.Add(xn, -1)
+	cn.Add(xc, 1)
 	return cn
 }
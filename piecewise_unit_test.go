@@ -2,9 +2,11 @@ package cgc_optimize
 
 import (
 	"fmt"
+	"math"
 	"testing"
 
 	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestNewPiecewiseUnit(t *testing.T) {
@@ -16,3 +18,67 @@ func TestNewPiecewiseUnit(t *testing.T) {
 
 	fmt.Println(pu)
 }
+
+func TestPiecewiseUnitIntegrality(t *testing.T) {
+	pid, _ := uuid.NewUUID()
+	cp := []CriticalPoint{CriticalPoint{-5, 0.8}, CriticalPoint{0, 0}, CriticalPoint{5, 1.2}}
+	pu := NewPiecewiseUnit(pid, cp)
+
+	assert.Equal(t, []int{0, 0, 0, 1, 1}, pu.Integrality())
+
+	g := NewGroup(pu, NewTestBasicUnit())
+	assert.Equal(t, []int{0, 0, 0, 1, 1, 0, 0, 0, 0}, g.Integrality())
+	s := NewSeries(NewCluster(g), NewCluster(g))
+	assert.Len(t, s.Integrality(), s.ColumnSize())
+	assert.Equal(t, 4, sumInts(s.Integrality()))
+}
+
+func TestPiecewiseUnitSegmentConstraints(t *testing.T) {
+	pid, _ := uuid.NewUUID()
+	cp := []CriticalPoint{CriticalPoint{-5, 0.8}, CriticalPoint{0, 0}, CriticalPoint{5, 1.2}}
+	pu := NewPiecewiseUnit(pid, cp)
+
+	inf := math.Inf(1)
+	pc := pu.Constraints()
+	assert.Len(t, pc, 4)
+	assert.Equal(t, []float64{-inf, -1, 0, 0, -5, 0, 0}, pc[0])
+	assert.Equal(t, []float64{-inf, 0, 1, 0, 0, 0, 0}, pc[1])
+	assert.Equal(t, []float64{-inf, 0, 0, 1, 0, -5, 0}, pc[2])
+	assert.Equal(t, []float64{1, 0, 0, 0, 1, 1, 1}, pc[3])
+}
+
+func TestPiecewiseUnitSolveMip(t *testing.T) {
+	pid, _ := uuid.NewUUID()
+	cp := []CriticalPoint{CriticalPoint{0, 1}, CriticalPoint{10, 2}, CriticalPoint{20, 3}}
+	pu := NewPiecewiseUnit(pid, cp)
+
+	g := NewGroup(pu)
+	err := g.NewSparseConstraint(NetLoadConstraint(&g, -8))
+	assert.Nil(t, err)
+	s := NewSeries(NewCluster(g))
+
+	res, err := NativeSolver{}.SolveMip(s, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDeltaSlice(t, []float64{0, 8, 0}, res.Columns[:3], 1e-6)
+	assert.InDelta(t, 1, res.Columns[3]+res.Columns[4], 1e-6)
+	assert.InDelta(t, 16, res.Objective, 1e-6)
+}
+
+func TestPiecewiseUnitSinglePoint(t *testing.T) {
+	pid, _ := uuid.NewUUID()
+	pu := NewPiecewiseUnit(pid, []CriticalPoint{NewCriticalPoint(10, 2)})
+
+	assert.Equal(t, []int{0}, pu.Integrality())
+	assert.Equal(t, [][2]float64{{0, 10}}, pu.Bounds())
+	assert.Empty(t, pu.Constraints())
+
+	c := NewSparseConstraint(4, math.Inf(1))
+	c.Add(0, 1)
+	err := pu.NewSparseConstraint(c)
+	assert.Nil(t, err)
+
+	res, err := NativeSolver{}.SolveMip(NewSeries(NewCluster(NewGroup(pu))), SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 4, res.Columns[0], 1e-6)
+	assert.InDelta(t, 8, res.Objective, 1e-6)
+}
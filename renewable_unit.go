@@ -0,0 +1,139 @@
+package cgc_optimize
+
+import (
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// CurtailmentLoc is implemented by units whose available power may be curtailed.
+type CurtailmentLoc interface {
+	AvailableLoc() []int
+	CurtailedLoc() []int
+}
+
+// RenewableUnit is a curtailable source, such as PV or wind, whose output is limited by
+// the power available at each time step.
+type RenewableUnit struct {
+	pid          uuid.UUID
+	coefficients []float64
+	bounds       [][2]float64
+	constraints  []SparseConstraint
+}
+
+var _ Unit = RenewableUnit{}
+var _ CurtailmentLoc = RenewableUnit{}
+
+// NewRenewableUnit returns a configured renewable unit struct. Available power is a fixed
+// column, the nameplate rating unless fixed for each time step of a series with
+// SetFixedProfile at AvailablePidLoc. Available power is either dispatched or curtailed.
+//
+// Cp: Cost coefficient for real positive power
+// Cu: Penalty cost for curtailed power
+//
+// XaUb: Nameplate rating, the limit of real positive power
+func NewRenewableUnit(pid uuid.UUID, Cp float64, Cu float64, XaUb float64) RenewableUnit {
+	coefficients := []float64{Cp, Cu, 0}
+	bounds := [][2]float64{{0, XaUb}, {0, math.Inf(1)}, {XaUb, XaUb}}
+
+	// xp + xu = xa
+	available := NewSparseConstraint(0, 0).Named("availability")
+	available.Add(0, 1)
+	available.Add(1, 1)
+	available.Add(2, -1)
+
+	return RenewableUnit{pid, coefficients, bounds, []SparseConstraint{available}}
+}
+
+func (u RenewableUnit) PID() uuid.UUID {
+	return u.pid
+}
+
+func (u RenewableUnit) CostCoefficients() []float64 {
+	return u.coefficients
+}
+
+func (u RenewableUnit) ColumnSize() int {
+	return len(u.coefficients)
+}
+
+// ColumnNames returns the name of each column: xp, xu and xa
+func (u RenewableUnit) ColumnNames() []string {
+	return []string{"xp", "xu", "xa"}
+}
+
+func (u RenewableUnit) Integrality() []int {
+	return make([]int, u.ColumnSize())
+}
+
+func (u *RenewableUnit) NewConstraint(t_c ...[]float64) error {
+	cx, err := validateDense(u.ColumnSize(), t_c)
+	if err != nil {
+		return err
+	}
+
+	// if no errors: add constraints to unit
+	u.constraints = append(u.constraints, cx...)
+	return nil
+}
+
+func (u *RenewableUnit) NewSparseConstraint(t_c ...SparseConstraint) error {
+	if err := validateSparse(u.ColumnSize(), t_c); err != nil {
+		return err
+	}
+
+	u.constraints = append(u.constraints, t_c...)
+	return nil
+}
+
+func (u RenewableUnit) Constraints() [][]float64 {
+	return densify(u.ColumnSize(), u.constraints)
+}
+
+func (u RenewableUnit) SparseConstraints() []SparseConstraint {
+	return u.constraints
+}
+
+func (u RenewableUnit) Bounds() [][2]float64 {
+	return u.bounds
+}
+
+func (u RenewableUnit) RealPositivePowerLoc() []int {
+	return []int{0}
+}
+
+func (u RenewableUnit) RealNegativePowerLoc() []int {
+	return []int{}
+}
+
+func (u RenewableUnit) RealCapacityLoc() []int {
+	return []int{}
+}
+
+func (u RenewableUnit) StoredEnergyLoc() []int {
+	return []int{}
+}
+
+func (u RenewableUnit) CurtailedLoc() []int {
+	return []int{1}
+}
+
+func (u RenewableUnit) AvailableLoc() []int {
+	return []int{2}
+}
+
+// availableLoc and curtailedLoc select the curtailment columns of u, empty if u cannot be
+// curtailed.
+func availableLoc(u Unit) []int {
+	if cu, ok := u.(CurtailmentLoc); ok {
+		return cu.AvailableLoc()
+	}
+	return []int{}
+}
+
+func curtailedLoc(u Unit) []int {
+	if cu, ok := u.(CurtailmentLoc); ok {
+		return cu.CurtailedLoc()
+	}
+	return []int{}
+}
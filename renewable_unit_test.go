@@ -0,0 +1,63 @@
+package cgc_optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRenewableUnit(t *testing.T) {
+	pid, _ := uuid.NewUUID()
+	u := NewRenewableUnit(pid, 0, 0.5, 10)
+
+	assert.Equal(t, []float64{0, 0.5, 0}, u.CostCoefficients())
+	assert.Equal(t, [][2]float64{{0, 10}, {0, math.Inf(1)}, {10, 10}}, u.Bounds())
+	assert.Equal(t, []string{"xp", "xu", "xa"}, u.ColumnNames())
+	assert.Equal(t, [][]float64{{0, 1, 1, -1, 0}}, u.Constraints())
+	assert.Equal(t, []int{0}, u.RealPositivePowerLoc())
+	assert.Equal(t, []int{1}, u.CurtailedLoc())
+	assert.Equal(t, []int{2}, u.AvailableLoc())
+	assert.Equal(t, []int{1}, curtailedLoc(u))
+	assert.Empty(t, curtailedLoc(NewTestBasicUnit()))
+}
+
+func TestRenewableUnitCurtailment(t *testing.T) {
+	pid, _ := uuid.NewUUID()
+	gridPID, _ := uuid.NewUUID()
+	pv := NewRenewableUnit(pid, 0, 0.1, 10)
+	grid := NewGridUnit(gridPID, 1, 0, math.Inf(1), 2)
+
+	clx := make([]Sequencer, 0)
+	for _, nl := range []float64{5, 5, 5} {
+		g := NewGroup(pv, grid)
+		err := g.NewSparseConstraint(NetLoadConstraint(&g, nl))
+		assert.Nil(t, err)
+		clx = append(clx, g)
+	}
+	s := NewSeries(clx...)
+
+	aLoc := s.AvailablePidLoc(pid)
+	assert.Len(t, aLoc, 3)
+	assert.Len(t, s.CurtailedPidLoc(pid), 3)
+	err := s.SetFixedProfile(aLoc, []float64{0, 4, 12})
+	assert.Nil(t, err)
+	assert.Equal(t, [2]float64{4, 4}, s.Bounds()[aLoc[1]])
+
+	res, err := NativeSolver{}.SolveLp(s, SolverOptions{})
+	assert.Nil(t, err)
+	srx, err := s.Decode(res.Columns)
+	assert.Nil(t, err)
+
+	// at t2, 12 available: 5 serve the load, 2 are exported and 5 curtailed, limited to
+	// the nameplate rating of 10
+	ux := UnitSeries(srx, pid)
+	assert.InDelta(t, 0, ux[0].CurtailedPower, 1e-6)
+	assert.InDelta(t, 4, ux[1].RealPositivePower, 1e-6)
+	assert.InDelta(t, 0, ux[1].CurtailedPower, 1e-6)
+	assert.InDelta(t, 7, ux[2].RealPositivePower, 1e-6)
+	assert.InDelta(t, 5, ux[2].CurtailedPower, 1e-6)
+	assert.InDelta(t, 1.25, ux[2].CurtailedEnergy(0.25), 1e-6)
+	assert.InDelta(t, 5+1+0.1*5, res.Objective, 1e-6)
+}
@@ -0,0 +1,233 @@
+package cgc_optimize
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// pidLocator locates the columns selected by a function of each unit with a PID, as
+// implemented by the PidLoc method of groups, clusters and series.
+type pidLocator func(uuid.UUID, func(Unit) []int) []int
+
+func realPositivePowerLoc(u Unit) []int { return u.RealPositivePowerLoc() }
+func realNegativePowerLoc(u Unit) []int { return u.RealNegativePowerLoc() }
+func realCapacityLoc(u Unit) []int      { return u.RealCapacityLoc() }
+
+// absorbingCapacityLoc selects the capacity columns of u if it can consume power.
+func absorbingCapacityLoc(u Unit) []int {
+	for _, i := range u.RealNegativePowerLoc() {
+		if u.Bounds()[i][1] > 0 {
+			return u.RealCapacityLoc()
+		}
+	}
+	return []int{}
+}
+
+// addHeadroom adds t_sign times the headroom of unit t_pid to t_c. Up headroom is
+// Xc - Xp + Xn, the increase in net output available from the unit's capacity. Down
+// headroom is Xp - Xn, with Xc added for units able to consume power, the decrease
+// available. An online generator cannot go below its minimum output, so its down headroom
+// is Xp - XpMin * on. Units without a capacity column have no headroom.
+func addHeadroom(t_c *SparseConstraint, t_loc pidLocator, t_pid uuid.UUID, t_up bool, t_sign float64) {
+	cLoc := t_loc(t_pid, realCapacityLoc)
+	if len(cLoc) == 0 {
+		return
+	}
+	pLoc := t_loc(t_pid, realPositivePowerLoc)
+	nLoc := t_loc(t_pid, realNegativePowerLoc)
+
+	dir := 1.0
+	if !t_up {
+		dir = -1.0
+	}
+	for _, i := range pLoc {
+		t_c.Add(i, -dir*t_sign)
+	}
+	for _, i := range nLoc {
+		t_c.Add(i, dir*t_sign)
+	}
+	if !t_up {
+		cLoc = t_loc(t_pid, absorbingCapacityLoc)
+
+		xpMin := make([]float64, 0)
+		onLoc := t_loc(t_pid, func(u Unit) []int {
+			mu, ok := u.(minimumOutputer)
+			if !ok {
+				return []int{}
+			}
+			for range mu.OnlineLoc() {
+				xpMin = append(xpMin, mu.MinimumOutput())
+			}
+			return mu.OnlineLoc()
+		})
+		for k, i := range onLoc {
+			t_c.Add(i, -xpMin[k]*t_sign)
+		}
+	}
+	for _, i := range cLoc {
+		t_c.Add(i, t_sign)
+	}
+}
+
+// reserveConstraint returns a constraint of the form: Sum_i(headroom_i) >= t_r over the
+// units t_pids.
+func reserveConstraint(t_loc pidLocator, t_pids []uuid.UUID, t_up bool, t_r float64, t_name string) SparseConstraint {
+	c := NewSparseConstraint(t_r, math.Inf(1)).Named(t_name)
+	for _, pid := range t_pids {
+		addHeadroom(&c, t_loc, pid, t_up, 1)
+	}
+	return c
+}
+
+// contingencyConstraints returns a constraint for each unit k of t_contingency of the
+// form: Sum_i!=k(headroom_i) - X_k >= 0 over the units t_pids, where X_k is the real
+// positive power of k for up reserve, and real negative power for down reserve.
+func contingencyConstraints(t_loc pidLocator, t_pids []uuid.UUID, t_contingency []uuid.UUID, t_up bool, t_name string) []SparseConstraint {
+	outage := realPositivePowerLoc
+	if !t_up {
+		outage = realNegativePowerLoc
+	}
+
+	cx := make([]SparseConstraint, 0)
+	for _, k := range t_contingency {
+		c := NewSparseConstraint(0, math.Inf(1)).Named(fmt.Sprintf("%v.%v", k, t_name))
+		for _, pid := range t_pids {
+			if pid != k {
+				addHeadroom(&c, t_loc, pid, t_up, 1)
+			}
+		}
+		for _, i := range t_loc(k, outage) {
+			c.Add(i, -1)
+		}
+		cx = append(cx, c)
+	}
+	return cx
+}
+
+// Group Reserve Constraints
+
+// GroupUpReserveConstraint returns a constraint of the form: Sum_i(Xc_i - Xp_i + Xn_i) >= t_r
+// over the units t_pids of the group.
+func GroupUpReserveConstraint(g *Group, t_pids []uuid.UUID, t_r float64) SparseConstraint {
+	return reserveConstraint(g.PidLoc, t_pids, true, t_r, "up_reserve")
+}
+
+// GroupDownReserveConstraint returns a constraint of the form: Sum_i(Xp_i - Xn_i) >= t_r
+// over the units t_pids of the group, with Xc_i added for units able to consume power and
+// XpMin_i * on_i subtracted for generators.
+func GroupDownReserveConstraint(g *Group, t_pids []uuid.UUID, t_r float64) SparseConstraint {
+	return reserveConstraint(g.PidLoc, t_pids, false, t_r, "down_reserve")
+}
+
+// GroupUpContingencyConstraints returns the N-1 up reserve constraints of the group: the
+// up headroom of the units t_pids, less unit k, covers the real positive power of each
+// unit k of t_contingency, and so the output of the largest of them.
+func GroupUpContingencyConstraints(g *Group, t_pids []uuid.UUID, t_contingency []uuid.UUID) []SparseConstraint {
+	return contingencyConstraints(g.PidLoc, t_pids, t_contingency, true, "up_contingency")
+}
+
+// GroupDownContingencyConstraints returns the N-1 down reserve constraints of the group:
+// the down headroom of the units t_pids, less unit k, covers the real negative power of
+// each unit k of t_contingency.
+func GroupDownContingencyConstraints(g *Group, t_pids []uuid.UUID, t_contingency []uuid.UUID) []SparseConstraint {
+	return contingencyConstraints(g.PidLoc, t_pids, t_contingency, false, "down_contingency")
+}
+
+// Cluster Reserve Constraints
+
+// ClusterUpReserveConstraint returns the up reserve constraint of GroupUpReserveConstraint
+// over the units t_pids of every group in the cluster. Linked units must not be included.
+func ClusterUpReserveConstraint(t_cl *Cluster, t_pids []uuid.UUID, t_r float64) SparseConstraint {
+	return reserveConstraint(t_cl.PidLoc, t_pids, true, t_r, "up_reserve")
+}
+
+// ClusterDownReserveConstraint returns the down reserve constraint of
+// GroupDownReserveConstraint over the units t_pids of every group in the cluster.
+func ClusterDownReserveConstraint(t_cl *Cluster, t_pids []uuid.UUID, t_r float64) SparseConstraint {
+	return reserveConstraint(t_cl.PidLoc, t_pids, false, t_r, "down_reserve")
+}
+
+// ClusterUpContingencyConstraints returns the N-1 up reserve constraints of
+// GroupUpContingencyConstraints over every group in the cluster.
+func ClusterUpContingencyConstraints(t_cl *Cluster, t_pids []uuid.UUID, t_contingency []uuid.UUID) []SparseConstraint {
+	return contingencyConstraints(t_cl.PidLoc, t_pids, t_contingency, true, "up_contingency")
+}
+
+// ClusterDownContingencyConstraints returns the N-1 down reserve constraints of
+// GroupDownContingencyConstraints over every group in the cluster.
+func ClusterDownContingencyConstraints(t_cl *Cluster, t_pids []uuid.UUID, t_contingency []uuid.UUID) []SparseConstraint {
+	return contingencyConstraints(t_cl.PidLoc, t_pids, t_contingency, false, "down_contingency")
+}
+
+// Series Reserve Constraints
+
+// SeriesUpReserveConstraints returns the up reserve constraint of the units t_pids at
+// each time step of the series, requiring t_r[i] at step i. It returns an error if t_r
+// does not hold a value for each time step.
+func SeriesUpReserveConstraints(t_se *Series, t_pids []uuid.UUID, t_r []float64) ([]SparseConstraint, error) {
+	return seriesReserveConstraints(t_se, t_pids, t_r, true, "up_reserve")
+}
+
+// SeriesDownReserveConstraints returns the down reserve constraint of the units t_pids at
+// each time step of the series, requiring t_r[i] at step i. It returns an error if t_r
+// does not hold a value for each time step.
+func SeriesDownReserveConstraints(t_se *Series, t_pids []uuid.UUID, t_r []float64) ([]SparseConstraint, error) {
+	return seriesReserveConstraints(t_se, t_pids, t_r, false, "down_reserve")
+}
+
+// SeriesUpContingencyConstraints returns the N-1 up reserve constraints at each time step
+// of the series.
+func SeriesUpContingencyConstraints(t_se *Series, t_pids []uuid.UUID, t_contingency []uuid.UUID) []SparseConstraint {
+	cx := make([]SparseConstraint, 0)
+	for k := range t_se.clusters {
+		for _, c := range contingencyConstraints(t_se.stepLocator(k), t_pids, t_contingency, true, "up_contingency") {
+			cx = append(cx, c.Named(fmt.Sprintf("%v.t%v", c.Name, k)))
+		}
+	}
+	return cx
+}
+
+// SeriesDownContingencyConstraints returns the N-1 down reserve constraints at each time
+// step of the series.
+func SeriesDownContingencyConstraints(t_se *Series, t_pids []uuid.UUID, t_contingency []uuid.UUID) []SparseConstraint {
+	cx := make([]SparseConstraint, 0)
+	for k := range t_se.clusters {
+		for _, c := range contingencyConstraints(t_se.stepLocator(k), t_pids, t_contingency, false, "down_contingency") {
+			cx = append(cx, c.Named(fmt.Sprintf("%v.t%v", c.Name, k)))
+		}
+	}
+	return cx
+}
+
+func seriesReserveConstraints(t_se *Series, t_pids []uuid.UUID, t_r []float64, t_up bool, t_name string) ([]SparseConstraint, error) {
+	if len(t_r) != len(t_se.clusters) {
+		err := fmt.Sprintf("%v profile contains %v values, expected: %v", t_name, len(t_r), len(t_se.clusters))
+		return []SparseConstraint{}, errors.New(err)
+	}
+
+	cx := make([]SparseConstraint, 0)
+	for k, r := range t_r {
+		cx = append(cx, reserveConstraint(t_se.stepLocator(k), t_pids, t_up, r, fmt.Sprintf("%v.t%v", t_name, k)))
+	}
+	return cx, nil
+}
+
+// stepLocator returns a pidLocator of the columns of the t_k-th time step of the series.
+func (se Series) stepLocator(t_k int) pidLocator {
+	offset := 0
+	for _, cl := range se.clusters[:t_k] {
+		offset += cl.ColumnSize()
+	}
+	cl := se.clusters[t_k]
+
+	return func(t_pid uuid.UUID, t_loc func(Unit) []int) []int {
+		loc := make([]int, 0)
+		for _, p := range cl.PidLoc(t_pid, t_loc) {
+			loc = append(loc, p+offset)
+		}
+		return loc
+	}
+}
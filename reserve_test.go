@@ -0,0 +1,134 @@
+package cgc_optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupReserveConstraints(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	pid2, _ := uuid.NewUUID()
+	pid3, _ := uuid.NewUUID()
+	gen := NewBasicUnit(pid1, 1, 0, 0, 0, 10, 0, 10, 0)
+	ess := NewBasicUnit(pid2, 0, 0, 0, 0, 5, 5, 5, 10)
+	grid := NewGridUnit(pid3, 1, 0, 100, 0)
+	g := NewGroup(gen, ess, grid)
+	pids := []uuid.UUID{pid1, pid2, pid3}
+	inf := math.Inf(1)
+
+	up := GroupUpReserveConstraint(&g, pids, 3)
+	assert.Equal(t, []float64{3, -1, 1, 1, 0, -1, 1, 1, 0, 0, 0, inf}, up.Dense(g.ColumnSize()))
+	assert.Equal(t, "up_reserve", up.Name)
+
+	down := GroupDownReserveConstraint(&g, pids, 2)
+	assert.Equal(t, []float64{2, 1, -1, 0, 0, 1, -1, 1, 0, 0, 0, inf}, down.Dense(g.ColumnSize()))
+
+	nx := GroupUpContingencyConstraints(&g, pids, []uuid.UUID{pid1})
+	assert.Len(t, nx, 1)
+	assert.Equal(t, pid1.String()+".up_contingency", nx[0].Name)
+	assert.Equal(t, []float64{0, -1, 0, 0, 0, -1, 1, 1, 0, 0, 0, inf}, nx[0].Dense(g.ColumnSize()))
+
+	nx = GroupDownContingencyConstraints(&g, pids, []uuid.UUID{pid2})
+	assert.Equal(t, []float64{0, 1, -1, 0, 0, 0, -1, 0, 0, 0, 0, inf}, nx[0].Dense(g.ColumnSize()))
+
+	assert.Equal(t, []int{2, 6}, append(g.RealCapacityPidLoc(pid1), g.RealCapacityPidLoc(pid2)...))
+}
+
+func TestGeneratorDownReserve(t *testing.T) {
+	genPID, _ := uuid.NewUUID()
+	gridPID, _ := uuid.NewUUID()
+	gen := NewGeneratorUnit(genPID, 1, 0, 0, 0, 0, 5, 20)
+	assert.Nil(t, gen.NewSparseConstraint(GeneratorUnitCapacityConstraints(&gen)...))
+	grid := NewGridUnit(gridPID, 2, 0, 100, 100)
+	g := NewGroup(gen, grid)
+	pids := []uuid.UUID{genPID, gridPID}
+
+	// an online generator can only back down to its minimum output
+	down := GroupDownReserveConstraint(&g, pids, 4)
+	assert.Equal(t, []float64{4, 1, 0, -5, 0, 0, 0, 0, math.Inf(1)}, down.Dense(g.ColumnSize()))
+
+	assert.Nil(t, g.NewSparseConstraint(NetLoadConstraint(&g, 8), down))
+	res, err := NativeSolver{}.SolveMip(NewSeries(NewCluster(g)), SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 9, res.Columns[0], 1e-6)
+	assert.InDelta(t, 9, res.Objective, 1e-6)
+}
+
+func TestSeriesReserveSolve(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	pid2, _ := uuid.NewUUID()
+
+	// two 10 kW gensets with online capacity, a cheap and an expensive one
+	gen1 := NewGeneratorUnit(pid1, 1, 0.1, 0, 0, 0, 0, 10)
+	gen1.NewSparseConstraint(GeneratorUnitCapacityConstraints(&gen1)...)
+	gen2 := NewGeneratorUnit(pid2, 2, 0.1, 0, 0, 0, 0, 10)
+	gen2.NewSparseConstraint(GeneratorUnitCapacityConstraints(&gen2)...)
+
+	clx := make([]Sequencer, 0)
+	for _, nl := range []float64{4, 8} {
+		g := NewGroup(gen1, gen2)
+		err := g.NewSparseConstraint(NetLoadConstraint(&g, nl))
+		assert.Nil(t, err)
+		clx = append(clx, NewCluster(g))
+	}
+	s := NewSeries(clx...)
+	pids := []uuid.UUID{pid1, pid2}
+
+	rx, err := SeriesUpReserveConstraints(&s, pids, []float64{2, 2})
+	assert.Nil(t, err)
+	assert.Len(t, rx, 2)
+	assert.Equal(t, "up_reserve.t1", rx[1].Name)
+	err = s.NewSparseConstraint(rx...)
+	assert.Nil(t, err)
+
+	_, err = SeriesUpReserveConstraints(&s, pids, []float64{2})
+	assert.Error(t, err)
+	_, err = SeriesDownReserveConstraints(&s, pids, []float64{2, 2, 2})
+	assert.Error(t, err)
+
+	nx := SeriesUpContingencyConstraints(&s, pids, pids)
+	assert.Len(t, nx, 4)
+	assert.Equal(t, pid2.String()+".up_contingency.t1", nx[3].Name)
+	err = s.NewSparseConstraint(nx...)
+	assert.Nil(t, err)
+
+	res, err := NativeSolver{}.SolveMip(s, SolverOptions{})
+	assert.Nil(t, err)
+	srx, err := s.Decode(res.Columns)
+	assert.Nil(t, err)
+
+	// N-1: the online capacity of the other genset covers each genset's output, so both
+	// are online and share the load
+	for k, nl := range []float64{4, 8} {
+		u1, u2 := srx[k][pid1], srx[k][pid2]
+		assert.InDelta(t, 1, u1.Online, 1e-6)
+		assert.InDelta(t, 1, u2.Online, 1e-6)
+		assert.LessOrEqual(t, u1.RealPositivePower, u2.RealCapacity+1e-6)
+		assert.LessOrEqual(t, u2.RealPositivePower, u1.RealCapacity+1e-6)
+		assert.InDelta(t, nl, u1.RealPositivePower+u2.RealPositivePower, 1e-6)
+	}
+}
+
+func TestClusterReserveConstraints(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	pid2, _ := uuid.NewUUID()
+	g1 := NewGroup(NewBasicUnit(pid1, 1, 0, 0, 0, 10, 0, 10, 0))
+	g2 := NewGroup(NewBasicUnit(pid2, 1, 0, 0, 0, 10, 0, 10, 0))
+	cl := NewCluster(g1, g2)
+	pids := []uuid.UUID{pid1, pid2}
+	inf := math.Inf(1)
+
+	up := ClusterUpReserveConstraint(&cl, pids, 5)
+	assert.Equal(t, []float64{5, -1, 1, 1, 0, -1, 1, 1, 0, inf}, up.Dense(cl.ColumnSize()))
+	down := ClusterDownReserveConstraint(&cl, pids, 5)
+	assert.Equal(t, []float64{5, 1, -1, 0, 0, 1, -1, 0, 0, inf}, down.Dense(cl.ColumnSize()))
+
+	nx := ClusterUpContingencyConstraints(&cl, pids, []uuid.UUID{pid2})
+	assert.Equal(t, []float64{0, -1, 1, 1, 0, -1, 0, 0, 0, inf}, nx[0].Dense(cl.ColumnSize()))
+	nx = ClusterDownContingencyConstraints(&cl, pids, []uuid.UUID{pid2})
+	assert.Equal(t, []float64{0, 1, -1, 0, 0, 0, -1, 0, 0, inf}, nx[0].Dense(cl.ColumnSize()))
+	assert.Equal(t, []int{6}, cl.RealCapacityPidLoc(pid2))
+}
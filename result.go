@@ -0,0 +1,90 @@
+package cgc_optimize
+
+import "fmt"
+
+// Status describes how a solve terminated.
+type Status int
+
+const (
+	StatusUnknown Status = iota
+	StatusOptimal
+	StatusInfeasible
+	StatusUnbounded
+	StatusInfeasibleOrUnbounded
+	StatusLimit
+	StatusError
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusOptimal:
+		return "optimal"
+	case StatusInfeasible:
+		return "infeasible"
+	case StatusUnbounded:
+		return "unbounded"
+	case StatusInfeasibleOrUnbounded:
+		return "infeasible or unbounded"
+	case StatusLimit:
+		return "limit reached"
+	case StatusError:
+		return "solver error"
+	default:
+		return "unknown"
+	}
+}
+
+// Result is the outcome of solving a linear program.
+//
+// Columns: primal value of each decision variable
+// Rows: activity of each constraint row
+// Duals: dual value of each constraint row, nil if the solver does not report them
+type Result struct {
+	Status    Status
+	Objective float64
+	Columns   []float64
+	Rows      []float64
+	Duals     []float64
+}
+
+// SolveError is returned when a solve terminates without an optimal solution.
+type SolveError struct {
+	Status Status
+}
+
+func (e SolveError) Error() string {
+	return fmt.Sprintf("solve terminated with status: %v", e.Status)
+}
+
+// NewResult returns an optimal result for w, deriving the objective value and row
+// activities from the primal column solution t_x.
+func NewResult(w LinearProgram, t_x []float64, t_duals []float64) Result {
+	return Result{
+		Status:    StatusOptimal,
+		Objective: ObjectiveValue(w, t_x),
+		Columns:   t_x,
+		Rows:      RowActivity(w, t_x),
+		Duals:     t_duals,
+	}
+}
+
+// ObjectiveValue returns the cost of solution t_x: Sum_i(c_i * x_i)
+func ObjectiveValue(w LinearProgram, t_x []float64) float64 {
+	var obj float64
+	for i, c := range w.CostCoefficients() {
+		obj += c * t_x[i]
+	}
+	return obj
+}
+
+// RowActivity returns the value of each constraint row of w evaluated at t_x.
+func RowActivity(w LinearProgram, t_x []float64) []float64 {
+	cx := SparseConstraintsOf(w)
+	rx := make([]float64, len(cx))
+	for i, c := range cx {
+		for k, j := range c.Index {
+			rx[i] += c.Value[k] * t_x[j]
+		}
+	}
+	return rx
+}
@@ -0,0 +1,34 @@
+package cgc_optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewResult(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	pid2, _ := uuid.NewUUID()
+	inf := math.Inf(1)
+	a1 := NewBasicUnit(pid1, 1, 2, 0, 0, inf, inf, inf, inf)
+	a2 := NewBasicUnit(pid2, 5, 6, 0, 0, inf, inf, inf, inf)
+	g := NewGroup(a1, a2)
+	err := g.NewSparseConstraint(NetLoadConstraint(&g, 10), GroupPositiveCapacityConstraint(&g, 12))
+	assert.Nil(t, err)
+
+	x := []float64{8, 0, 8, 0, 3, 1, 4, 0}
+	r := NewResult(g, x, nil)
+
+	assert.Equal(t, StatusOptimal, r.Status)
+	assert.Equal(t, 8.0*1+3*5+1*6, r.Objective)
+	assert.Equal(t, x, r.Columns)
+	assert.Equal(t, []float64{10, 12}, r.Rows)
+	assert.Nil(t, r.Duals)
+}
+
+func TestSolveError(t *testing.T) {
+	err := SolveError{StatusInfeasible}
+	assert.Equal(t, "solve terminated with status: infeasible", err.Error())
+}
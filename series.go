@@ -3,22 +3,43 @@ package cgc_optimize
 import (
 	"errors"
 	"fmt"
+	"math"
 
 	"github.com/google/uuid"
 )
 
 type Series struct {
 	clusters    []Sequencer
-	constraints [][]float64
+	constraints []SparseConstraint
+	cost        map[int]float64 // cost coefficient overrides by column
+	upper       map[int]float64 // upper bound overrides by column
+	lower       map[int]float64 // lower bound overrides by column
+	aux         []auxColumn
+	emissions   map[uuid.UUID]EmissionFactor // emission factors by unit
 }
 
+// auxColumn is a column of the series not belonging to any time step
+type auxColumn struct {
+	name   string
+	cost   float64
+	bounds [2]float64
+	owner  uuid.UUID // unit whose cost includes the column, uuid.Nil if none
+}
+
+var _ MipLinearProgram = Series{}
+
 type Sequencer interface {
 	CostCoefficients() []float64
 	Constraints() [][]float64
+	SparseConstraints() []SparseConstraint
 	Bounds() [][2]float64
 	ColumnSize() int
+	ColumnNames() []string
+	Integrality() []int
 	PowerLoc
 	StorageLoc
+	UnitLoc
+	Decoder
 }
 
 type PowerLoc interface {
@@ -30,8 +51,23 @@ type StorageLoc interface {
 	StoredEnergyPidLoc(uuid.UUID) []int
 }
 
+// UnitLoc locates arbitrary unit columns, selected by a function of the unit.
+type UnitLoc interface {
+	PidLoc(uuid.UUID, func(Unit) []int) []int
+}
+
 func NewSeries(sequence ...Sequencer) Series {
-	return Series{sequence, [][]float64{}}
+	return Series{sequence, []SparseConstraint{}, map[int]float64{}, map[int]float64{}, map[int]float64{}, []auxColumn{}, map[uuid.UUID]EmissionFactor{}}
+}
+
+// NewSeriesFromTemplate returns a series of t_n time steps, each a copy of t_template.
+// Steps are varied with SetCostProfile and SetUpperBoundProfile.
+func NewSeriesFromTemplate(t_template Sequencer, t_n int) Series {
+	sequence := make([]Sequencer, t_n)
+	for k := range sequence {
+		sequence[k] = t_template
+	}
+	return NewSeries(sequence...)
 }
 
 func (se Series) CostCoefficients() []float64 {
@@ -39,7 +75,13 @@ func (se Series) CostCoefficients() []float64 {
 	for _, cl := range se.clusters {
 		cc = append(cc, cl.CostCoefficients()...)
 	}
+	for _, a := range se.aux {
+		cc = append(cc, a.cost)
+	}
 
+	for j, c := range se.cost {
+		cc[j] = c
+	}
 	return cc
 }
 
@@ -49,23 +91,169 @@ func (se Series) Bounds() [][2]float64 {
 	for _, cl := range se.clusters {
 		b = append(b, cl.Bounds()...)
 	}
+	for _, a := range se.aux {
+		b = append(b, a.bounds)
+	}
+
+	for j, ub := range se.upper {
+		b[j][1] = ub
+	}
+	for j, lb := range se.lower {
+		b[j][0] = lb
+	}
 	return b
 }
 
+// SetCostProfile overrides the cost coefficient of each column in t_loc with the value
+// at the same index of t_cost, e.g. a time-of-use tariff applied to
+// RealPositivePowerPidLoc.
+func (se *Series) SetCostProfile(t_loc []int, t_cost []float64) error {
+	if err := validateProfile(se.ColumnSize(), t_loc, t_cost); err != nil {
+		return err
+	}
+
+	se.cost = cloneOverrides(se.cost)
+	for i, j := range t_loc {
+		se.cost[j] = t_cost[i]
+	}
+	return nil
+}
+
+// SetUpperBoundProfile overrides the upper bound of each column in t_loc with the value
+// at the same index of t_ub, e.g. a PV forecast applied to RealPositivePowerPidLoc. The
+// direction constraints of a unit use its own power bounds as big-M, so an upper bound
+// above them is an error.
+func (se *Series) SetUpperBoundProfile(t_loc []int, t_ub []float64) error {
+	if err := validateProfile(se.ColumnSize(), t_loc, t_ub); err != nil {
+		return err
+	}
+
+	b := se.Bounds()
+	m := se.directionLimits()
+	for i, j := range t_loc {
+		if t_ub[i] < b[j][0] {
+			err := fmt.Sprintf("upper bound %v of column %v is below its lower bound %v", t_ub[i], j, b[j][0])
+			return errors.New(err)
+		}
+		if M, ok := m[j]; ok && t_ub[i] > M {
+			err := fmt.Sprintf("upper bound %v of column %v is above its direction limit %v", t_ub[i], j, M)
+			return errors.New(err)
+		}
+	}
+
+	se.upper = cloneOverrides(se.upper)
+	for i, j := range t_loc {
+		se.upper[j] = t_ub[i]
+	}
+	return nil
+}
+
+// SetFixedProfile fixes each column in t_loc to the value at the same index of
+// t_values, e.g. a PV forecast applied to AvailablePidLoc.
+func (se *Series) SetFixedProfile(t_loc []int, t_values []float64) error {
+	if err := validateProfile(se.ColumnSize(), t_loc, t_values); err != nil {
+		return err
+	}
+
+	se.upper = cloneOverrides(se.upper)
+	se.lower = cloneOverrides(se.lower)
+	for i, j := range t_loc {
+		se.upper[j] = t_values[i]
+		se.lower[j] = t_values[i]
+	}
+	return nil
+}
+
+// directionLimits returns the big-M of each power column of the series limited by the
+// direction binary of its unit, by column.
+func (se Series) directionLimits() map[int]float64 {
+	m := make(map[int]float64)
+	offset := 0
+	for _, seq := range se.clusters {
+		eachUnit(seq, func(u Unit, t_offset int) {
+			if d, ok := u.(directionLimiter); ok {
+				for j, M := range d.directionLimits() {
+					m[offset+t_offset+j] = M
+				}
+			}
+		})
+		offset += seq.ColumnSize()
+	}
+	return m
+}
+
+// eachUnit calls t_f with each unit of t_seq and the index of its first column. Sequencers
+// other than groups and clusters have no units to visit.
+func eachUnit(t_seq Sequencer, t_f func(Unit, int)) {
+	switch seq := t_seq.(type) {
+	case Group:
+		i := 0
+		for _, u := range seq.units {
+			t_f(u, i)
+			i += u.ColumnSize()
+		}
+	case *Group:
+		eachUnit(*seq, t_f)
+	case Cluster:
+		i := 0
+		for _, g := range seq.groups {
+			eachUnit(g, func(u Unit, t_offset int) { t_f(u, i+t_offset) })
+			i += g.ColumnSize()
+		}
+	case *Cluster:
+		eachUnit(*seq, t_f)
+	}
+}
+
+// cloneOverrides returns a copy of t_m. The override maps are copied before each write,
+// so copies of a series do not share the profiles set after they were copied.
+func cloneOverrides(t_m map[int]float64) map[int]float64 {
+	m := make(map[int]float64, len(t_m))
+	for j, v := range t_m {
+		m[j] = v
+	}
+	return m
+}
+
+// validateProfile checks that t_values has one value for each column of t_loc, and each
+// column is within a program of t_size columns.
+func validateProfile(t_size int, t_loc []int, t_values []float64) error {
+	if len(t_loc) != len(t_values) {
+		err := fmt.Sprintf("profile length %v does not match number of columns %v", len(t_values), len(t_loc))
+		return errors.New(err)
+	}
+
+	for _, j := range t_loc {
+		if j < 0 || j >= t_size {
+			err := fmt.Sprintf("column %v out of range [0, %v)", j, t_size)
+			return errors.New(err)
+		}
+	}
+	return nil
+}
+
+// Integrality returns the integer mask of the series columns.
+func (se Series) Integrality() []int {
+	mask := make([]int, 0)
+	for _, cl := range se.clusters {
+		mask = append(mask, cl.Integrality()...)
+	}
+	mask = append(mask, make([]int, len(se.aux))...)
+	return mask
+}
+
 func (se Series) Constraints() [][]float64 {
-	s := se.ColumnSize()
-	sec := make([][]float64, 0) // Series Constraint
+	return densify(se.ColumnSize(), se.SparseConstraints())
+}
+
+// SparseConstraints returns the constraints of each step, shifted to the step's columns,
+// followed by the series constraints.
+func (se Series) SparseConstraints() []SparseConstraint {
+	sec := make([]SparseConstraint, 0) // Series Constraint
 
 	i := 0
-	for _, cl := range se.clusters {
-		pre := make([]float64, i+1)
-		post := make([]float64, s-i-cl.ColumnSize()+1)
-		for _, clc := range cl.Constraints() {
-			pre[0] = lb(clc)            // shift lower bound to index 0
-			post[len(post)-1] = ub(clc) // shift upper bound at last index
-			c := append(append(pre, cons(clc)...), post...)
-			sec = append(sec, c)
-		}
+	for k, cl := range se.clusters {
+		sec = shiftConstraints(sec, cl.SparseConstraints(), i, stepPrefix(k))
 		i += cl.ColumnSize()
 	}
 
@@ -73,28 +261,83 @@ func (se Series) Constraints() [][]float64 {
 	return sec
 }
 
-func (se *Series) NewConstraint(t_c ...[]float64) error {
-	cx := make([][]float64, 0)
-	for _, c := range t_c {
-		if len(c) != se.ColumnSize()+2 {
-			err := fmt.Sprintf("constraint contains %v columns, expected: %v", len(c), se.ColumnSize()+2)
-			return errors.New(err)
-		}
-		cx = append(cx, c)
+// ColumnNames returns the name of each column, qualified by time step.
+func (se Series) ColumnNames() []string {
+	names := make([]string, 0)
+	for k, cl := range se.clusters {
+		names = append(names, prefixNames(stepPrefix(k), cl.ColumnNames())...)
+	}
+	for _, a := range se.aux {
+		names = append(names, a.name)
+	}
+	return names
+}
+
+// ConstraintNames returns the name of each constraint row.
+func (se Series) ConstraintNames() []string {
+	return constraintNames(se.SparseConstraints())
+}
 
+// stepPrefix returns the qualifier of the names of the t_k-th time step within a series
+func stepPrefix(t_k int) string {
+	return fmt.Sprintf("t%v.", t_k)
+}
+
+func (se *Series) NewConstraint(t_c ...[]float64) error {
+	cx, err := validateDense(se.ColumnSize(), t_c)
+	if err != nil {
+		return err
 	}
 
 	se.constraints = append(se.constraints, cx...)
 	return nil
 }
 
+func (se *Series) NewSparseConstraint(t_c ...SparseConstraint) error {
+	if err := validateSparse(se.ColumnSize(), t_c); err != nil {
+		return err
+	}
+
+	se.constraints = append(se.constraints, t_c...)
+	return nil
+}
+
 func (se *Series) ColumnSize() int {
 	var s int
 	for _, cl := range se.clusters {
 		s += cl.ColumnSize()
 	}
 
-	return s
+	return s + len(se.aux)
+}
+
+// NewAuxiliaryColumn appends a continuous column spanning the whole series, such as the
+// peak import of a demand charge, and returns its index. Auxiliary columns follow the
+// columns of every time step.
+func (se *Series) NewAuxiliaryColumn(t_name string, t_cost float64, t_lb float64, t_ub float64) int {
+	se.aux = append(se.aux[:len(se.aux):len(se.aux)], auxColumn{t_name, t_cost, [2]float64{t_lb, t_ub}, uuid.Nil})
+	return se.ColumnSize() - 1
+}
+
+// NewUnitAuxiliaryColumn appends an auxiliary column owned by unit t_pid, named
+// "<pid>.<t_name>", and returns its index. The cost of an owned column is part of the
+// CostTerm of its unit.
+func (se *Series) NewUnitAuxiliaryColumn(t_pid uuid.UUID, t_name string, t_cost float64, t_lb float64, t_ub float64) int {
+	j := se.NewAuxiliaryColumn(fmt.Sprintf("%v.%v", t_pid, t_name), t_cost, t_lb, t_ub)
+	se.aux[len(se.aux)-1].owner = t_pid
+	return j
+}
+
+// AuxiliaryPidLoc returns the auxiliary columns owned by unit t_pid.
+func (se Series) AuxiliaryPidLoc(t_pid uuid.UUID) []int {
+	loc := make([]int, 0)
+	offset := se.ColumnSize() - len(se.aux)
+	for k, a := range se.aux {
+		if a.owner == t_pid {
+			loc = append(loc, offset+k)
+		}
+	}
+	return loc
 }
 
 func (se Series) RealPositivePowerPidLoc(t_pid uuid.UUID) []int {
@@ -136,32 +379,425 @@ func (se Series) StoredEnergyPidLoc(t_pid uuid.UUID) []int {
 	return loc
 }
 
+// RealCapacityPidLoc returns the real capacity columns of each unit with PID t_pid.
+func (se Series) RealCapacityPidLoc(t_pid uuid.UUID) []int {
+	return se.PidLoc(t_pid, realCapacityLoc)
+}
+
+// PidLoc returns the columns selected by t_loc of each unit with PID t_pid.
+func (se Series) PidLoc(t_pid uuid.UUID, t_loc func(Unit) []int) []int {
+	loc := make([]int, 0)
+	i := 0
+	for _, cl := range se.clusters {
+		for _, p := range cl.PidLoc(t_pid, t_loc) {
+			loc = append(loc, p+i)
+		}
+		i += cl.ColumnSize()
+	}
+
+	return loc
+}
+
+// OnlinePidLoc returns the online column of unit t_pid at each time step.
+func (se Series) OnlinePidLoc(t_pid uuid.UUID) []int {
+	return se.PidLoc(t_pid, onlineLoc)
+}
+
+// AvailablePidLoc returns the available power column of unit t_pid at each time step.
+func (se Series) AvailablePidLoc(t_pid uuid.UUID) []int {
+	return se.PidLoc(t_pid, availableLoc)
+}
+
+// CurtailedPidLoc returns the curtailed power column of unit t_pid at each time step.
+func (se Series) CurtailedPidLoc(t_pid uuid.UUID) []int {
+	return se.PidLoc(t_pid, curtailedLoc)
+}
+
+// DemandPidLoc returns the demand column of unit t_pid at each time step.
+func (se Series) DemandPidLoc(t_pid uuid.UUID) []int {
+	return se.PidLoc(t_pid, demandLoc)
+}
+
+// ShedPidLoc returns the shed demand column of unit t_pid at each time step.
+func (se Series) ShedPidLoc(t_pid uuid.UUID) []int {
+	return se.PidLoc(t_pid, shedLoc)
+}
+
+// StartUpPidLoc returns the start up column of unit t_pid at each time step.
+func (se Series) StartUpPidLoc(t_pid uuid.UUID) []int {
+	return se.PidLoc(t_pid, startUpLoc)
+}
+
+// ShutDownPidLoc returns the shut down column of unit t_pid at each time step.
+func (se Series) ShutDownPidLoc(t_pid uuid.UUID) []int {
+	return se.PidLoc(t_pid, shutDownLoc)
+}
+
 // BatteryInitialEnergyConstraint returns a constraint of the form: e_t0 = t_e
-func BatteryInitialEnergyConstraint(t_se *Series, t_pid uuid.UUID, t_e float64) []float64 {
+func BatteryInitialEnergyConstraint(t_se *Series, t_pid uuid.UUID, t_e float64) SparseConstraint {
 	eLoc := t_se.StoredEnergyPidLoc(t_pid)
-	c := make([]float64, t_se.ColumnSize())
-	c[eLoc[0]] = 1
-	c = boundConstraint(c, t_e, t_e)
+	c := NewSparseConstraint(t_e, t_e).Named(t_pid.String() + ".initial_energy")
+	c.Add(eLoc[0], 1)
 
 	return c
 }
 
 // BatteryEnergyConstraint returns a constraint of the form: e_ti - (p_ti-n_ti)*t = e_t(i+1)
-func BatteryEnergyConstraint(t_se *Series, t_pid uuid.UUID, t_tstep float64) [][]float64 {
+func BatteryEnergyConstraint(t_se *Series, t_pid uuid.UUID, t_tstep float64) []SparseConstraint {
+	return batteryEnergyBalance(t_se, t_pid, t_tstep, 1, 1, 0)
+}
+
+// BatteryEnergyEfficiencyConstraint returns a constraint of the form:
+// (1-t_sd)*e_ti - (p_ti/t_etaD)*t + (t_etaC*n_ti)*t = e_t(i+1)
+//
+// t_etaC: Charge efficiency, (0, 1]
+// t_etaD: Discharge efficiency, (0, 1]
+// t_sd: Fraction of stored energy lost each time step, [0, 1)
+//
+// It returns an error if an efficiency or the self discharge is out of range.
+func BatteryEnergyEfficiencyConstraint(t_se *Series, t_pid uuid.UUID, t_tstep float64, t_etaC float64, t_etaD float64, t_sd float64) ([]SparseConstraint, error) {
+	if err := validateStorageLosses(t_etaC, t_etaD, t_sd); err != nil {
+		return []SparseConstraint{}, err
+	}
+	return batteryEnergyBalance(t_se, t_pid, t_tstep, t_etaC, t_etaD, t_sd), nil
+}
+
+// batteryEnergyBalance returns the constraints of BatteryEnergyEfficiencyConstraint without
+// checking the efficiencies.
+func batteryEnergyBalance(t_se *Series, t_pid uuid.UUID, t_tstep float64, t_etaC float64, t_etaD float64, t_sd float64) []SparseConstraint {
 	pLoc := t_se.RealPositivePowerPidLoc(t_pid)
 	nLoc := t_se.RealNegativePowerPidLoc(t_pid)
 	eLoc := t_se.StoredEnergyPidLoc(t_pid)
 
-	cx := make([][]float64, 0)
+	cx := make([]SparseConstraint, 0)
 	for i := 0; i < len(eLoc)-1; i++ {
-		c := make([]float64, t_se.ColumnSize())
-		c[pLoc[i]] = -t_tstep
-		c[nLoc[i]] = t_tstep
-		c[eLoc[i]] = 1
-		c[eLoc[i+1]] = -1
-		c = boundConstraint(c, 0, 0)
+		c := NewSparseConstraint(0, 0).Named(fmt.Sprintf("%v.energy_balance.t%v", t_pid, i))
+		c.Add(pLoc[i], -t_tstep/t_etaD)
+		c.Add(nLoc[i], t_etaC*t_tstep)
+		c.Add(eLoc[i], 1-t_sd)
+		c.Add(eLoc[i+1], -1)
 		cx = append(cx, c)
 	}
 
 	return cx
 }
+
+// validateStorageLosses returns an error unless the efficiencies t_etaC and t_etaD are in
+// (0, 1] and the self discharge t_sd in [0, 1).
+func validateStorageLosses(t_etaC float64, t_etaD float64, t_sd float64) error {
+	if t_etaC <= 0 || t_etaC > 1 || t_etaD <= 0 || t_etaD > 1 {
+		err := fmt.Sprintf("efficiencies %v and %v outside of (0, 1]", t_etaC, t_etaD)
+		return errors.New(err)
+	}
+	if t_sd < 0 || t_sd >= 1 {
+		err := fmt.Sprintf("self discharge %v outside of [0, 1)", t_sd)
+		return errors.New(err)
+	}
+	return nil
+}
+
+// batteryFinalEnergy returns a constraint of the form:
+// t_lb <= (1-t_sd)*e_tN - (p_tN/t_etaD)*t + (t_etaC*n_tN)*t <= t_ub
+//
+// bounding the stored energy left after the final time step tN. The energy balance links
+// each time step to the next only, so e_tN is the stored energy at the start of the final
+// step; its charge and discharge are accounted for here. It returns an error if an
+// efficiency or the self discharge is out of range.
+func batteryFinalEnergy(t_se *Series, t_pid uuid.UUID, t_tstep float64, t_etaC float64, t_etaD float64, t_sd float64, t_lb float64, t_ub float64, t_name string) (SparseConstraint, error) {
+	if err := validateStorageLosses(t_etaC, t_etaD, t_sd); err != nil {
+		return SparseConstraint{}, err
+	}
+
+	pLoc := t_se.RealPositivePowerPidLoc(t_pid)
+	nLoc := t_se.RealNegativePowerPidLoc(t_pid)
+	eLoc := t_se.StoredEnergyPidLoc(t_pid)
+	n := len(eLoc) - 1
+
+	c := NewSparseConstraint(t_lb, t_ub).Named(t_pid.String() + "." + t_name)
+	c.Add(pLoc[n], -t_tstep/t_etaD)
+	c.Add(nLoc[n], t_etaC*t_tstep)
+	c.Add(eLoc[n], 1-t_sd)
+
+	return c, nil
+}
+
+// BatteryFinalEnergyConstraint returns a constraint of the form: 0 <= e_end <= XeUb, where
+// e_end is the stored energy after the final time step, as in BatteryEnergyEfficiencyConstraint,
+// and XeUb the stored energy bound of the final time step. Without it the final step's
+// power is not limited by stored energy.
+func BatteryFinalEnergyConstraint(t_se *Series, t_pid uuid.UUID, t_tstep float64, t_etaC float64, t_etaD float64, t_sd float64) (SparseConstraint, error) {
+	eLoc := t_se.StoredEnergyPidLoc(t_pid)
+	b := t_se.Bounds()[eLoc[len(eLoc)-1]]
+	return batteryFinalEnergy(t_se, t_pid, t_tstep, t_etaC, t_etaD, t_sd, b[0], b[1], "final_energy")
+}
+
+// BatteryTerminalEnergyConstraint returns a constraint of the form: e_end = t_e, where
+// e_end is the stored energy after the final time step
+func BatteryTerminalEnergyConstraint(t_se *Series, t_pid uuid.UUID, t_tstep float64, t_etaC float64, t_etaD float64, t_sd float64, t_e float64) (SparseConstraint, error) {
+	return batteryFinalEnergy(t_se, t_pid, t_tstep, t_etaC, t_etaD, t_sd, t_e, t_e, "terminal_energy")
+}
+
+// BatteryMinimumTerminalEnergyConstraint returns a constraint of the form: e_end >= t_e
+func BatteryMinimumTerminalEnergyConstraint(t_se *Series, t_pid uuid.UUID, t_tstep float64, t_etaC float64, t_etaD float64, t_sd float64, t_e float64) (SparseConstraint, error) {
+	return batteryFinalEnergy(t_se, t_pid, t_tstep, t_etaC, t_etaD, t_sd, t_e, math.Inf(1), "min_terminal_energy")
+}
+
+// BatteryCyclicEnergyConstraint returns a constraint of the form: e_end - e_t0 = 0
+func BatteryCyclicEnergyConstraint(t_se *Series, t_pid uuid.UUID, t_tstep float64, t_etaC float64, t_etaD float64, t_sd float64) (SparseConstraint, error) {
+	eLoc := t_se.StoredEnergyPidLoc(t_pid)
+	c, err := batteryFinalEnergy(t_se, t_pid, t_tstep, t_etaC, t_etaD, t_sd, 0, 0, "cyclic_energy")
+	if err != nil {
+		return SparseConstraint{}, err
+	}
+	c.Add(eLoc[0], -1)
+
+	return c, nil
+}
+
+// BatteryEnergyWindowConstraints returns a constraint for each time step of the form:
+// t_min_i <= e_ti <= t_max_i
+//
+// t_min, t_max hold one value per time step. It returns an error if either differs in
+// length from the series.
+func BatteryEnergyWindowConstraints(t_se *Series, t_pid uuid.UUID, t_min []float64, t_max []float64) ([]SparseConstraint, error) {
+	eLoc := t_se.StoredEnergyPidLoc(t_pid)
+	if len(t_min) != len(eLoc) || len(t_max) != len(eLoc) {
+		err := fmt.Sprintf("energy window of unit %v contains %v minimum and %v maximum values, expected: %v", t_pid, len(t_min), len(t_max), len(eLoc))
+		return []SparseConstraint{}, errors.New(err)
+	}
+
+	cx := make([]SparseConstraint, 0)
+	for i, e := range eLoc {
+		c := NewSparseConstraint(t_min[i], t_max[i]).Named(fmt.Sprintf("%v.energy_window.t%v", t_pid, i))
+		c.Add(e, 1)
+		cx = append(cx, c)
+	}
+
+	return cx, nil
+}
+
+// BatteryReserveConstraints returns a constraint for each time step of the form:
+// e_ti >= t_reserve, and one of the form e_end >= t_reserve for the stored energy after the
+// final time step
+func BatteryReserveConstraints(t_se *Series, t_pid uuid.UUID, t_tstep float64, t_etaC float64, t_etaD float64, t_sd float64, t_reserve float64) ([]SparseConstraint, error) {
+	end, err := batteryFinalEnergy(t_se, t_pid, t_tstep, t_etaC, t_etaD, t_sd, t_reserve, math.Inf(1), "energy_reserve.end")
+	if err != nil {
+		return []SparseConstraint{}, err
+	}
+
+	eLoc := t_se.StoredEnergyPidLoc(t_pid)
+
+	cx := make([]SparseConstraint, 0)
+	for i, e := range eLoc {
+		c := NewSparseConstraint(t_reserve, math.Inf(1)).Named(fmt.Sprintf("%v.energy_reserve.t%v", t_pid, i))
+		c.Add(e, 1)
+		cx = append(cx, c)
+	}
+	cx = append(cx, end)
+
+	return cx, nil
+}
+
+// GeneratorInitialStatusConstraint returns a constraint of the form:
+// on_t0 - su_t0 + sd_t0 = t_online, where t_online is 1 if the generator is online before
+// the first time step
+func GeneratorInitialStatusConstraint(t_se *Series, t_pid uuid.UUID, t_online bool) SparseConstraint {
+	onLoc := t_se.OnlinePidLoc(t_pid)
+	suLoc := t_se.StartUpPidLoc(t_pid)
+	sdLoc := t_se.ShutDownPidLoc(t_pid)
+
+	status := 0.0
+	if t_online {
+		status = 1
+	}
+
+	c := NewSparseConstraint(status, status).Named(t_pid.String() + ".initial_status")
+	c.Add(onLoc[0], 1)
+	c.Add(suLoc[0], -1)
+	c.Add(sdLoc[0], 1)
+
+	return c
+}
+
+// GeneratorTransitionConstraints returns a constraint for each consecutive pair of time
+// steps of the form: on_t(i+1) - on_ti - su_t(i+1) + sd_t(i+1) = 0
+func GeneratorTransitionConstraints(t_se *Series, t_pid uuid.UUID) []SparseConstraint {
+	onLoc := t_se.OnlinePidLoc(t_pid)
+	suLoc := t_se.StartUpPidLoc(t_pid)
+	sdLoc := t_se.ShutDownPidLoc(t_pid)
+
+	cx := make([]SparseConstraint, 0)
+	for i := 0; i < len(onLoc)-1; i++ {
+		c := NewSparseConstraint(0, 0).Named(fmt.Sprintf("%v.transition.t%v", t_pid, i+1))
+		c.Add(onLoc[i+1], 1)
+		c.Add(onLoc[i], -1)
+		c.Add(suLoc[i+1], -1)
+		c.Add(sdLoc[i+1], 1)
+		cx = append(cx, c)
+	}
+
+	return cx
+}
+
+// GeneratorMinimumUpTimeConstraints returns a constraint for each time step of the form:
+// Sum_k(su_tk) - on_ti <= 0, for k in (i-t_up, i], so a generator started within the last
+// t_up time steps remains online
+func GeneratorMinimumUpTimeConstraints(t_se *Series, t_pid uuid.UUID, t_up int) []SparseConstraint {
+	onLoc := t_se.OnlinePidLoc(t_pid)
+	suLoc := t_se.StartUpPidLoc(t_pid)
+
+	cx := make([]SparseConstraint, 0)
+	for i := range onLoc {
+		c := NewSparseConstraint(math.Inf(-1), 0).Named(fmt.Sprintf("%v.min_up.t%v", t_pid, i))
+		for k := i - t_up + 1; k <= i; k++ {
+			if k >= 0 {
+				c.Add(suLoc[k], 1)
+			}
+		}
+		c.Add(onLoc[i], -1)
+		cx = append(cx, c)
+	}
+
+	return cx
+}
+
+// GeneratorMinimumDownTimeConstraints returns a constraint for each time step of the form:
+// Sum_k(sd_tk) + on_ti <= 1, for k in (i-t_down, i], so a generator shut down within the
+// last t_down time steps remains offline
+func GeneratorMinimumDownTimeConstraints(t_se *Series, t_pid uuid.UUID, t_down int) []SparseConstraint {
+	onLoc := t_se.OnlinePidLoc(t_pid)
+	sdLoc := t_se.ShutDownPidLoc(t_pid)
+
+	cx := make([]SparseConstraint, 0)
+	for i := range onLoc {
+		c := NewSparseConstraint(math.Inf(-1), 1).Named(fmt.Sprintf("%v.min_down.t%v", t_pid, i))
+		for k := i - t_down + 1; k <= i; k++ {
+			if k >= 0 {
+				c.Add(sdLoc[k], 1)
+			}
+		}
+		c.Add(onLoc[i], 1)
+		cx = append(cx, c)
+	}
+
+	return cx
+}
+
+// GeneratorInitialUpTimeConstraints returns a constraint of the form: on_ti >= 1 for each
+// of the first t_up - t_on time steps, where the generator has been online for t_on time
+// steps before the first, so a generator started shortly before the series completes its
+// minimum up time
+func GeneratorInitialUpTimeConstraints(t_se *Series, t_pid uuid.UUID, t_up int, t_on int) []SparseConstraint {
+	onLoc := t_se.OnlinePidLoc(t_pid)
+
+	cx := make([]SparseConstraint, 0)
+	for i := 0; i < t_up-t_on && i < len(onLoc); i++ {
+		c := NewSparseConstraint(1, math.Inf(1)).Named(fmt.Sprintf("%v.initial_min_up.t%v", t_pid, i))
+		c.Add(onLoc[i], 1)
+		cx = append(cx, c)
+	}
+
+	return cx
+}
+
+// GeneratorInitialDownTimeConstraints returns a constraint of the form: on_ti <= 0 for
+// each of the first t_down - t_off time steps, where the generator has been offline for
+// t_off time steps before the first, so a generator shut down shortly before the series
+// completes its minimum down time
+func GeneratorInitialDownTimeConstraints(t_se *Series, t_pid uuid.UUID, t_down int, t_off int) []SparseConstraint {
+	onLoc := t_se.OnlinePidLoc(t_pid)
+
+	cx := make([]SparseConstraint, 0)
+	for i := 0; i < t_down-t_off && i < len(onLoc); i++ {
+		c := NewSparseConstraint(math.Inf(-1), 0).Named(fmt.Sprintf("%v.initial_min_down.t%v", t_pid, i))
+		c.Add(onLoc[i], 1)
+		cx = append(cx, c)
+	}
+
+	return cx
+}
+
+// RampRateConstraints returns a constraint for each consecutive pair of time steps of the
+// form: -t_down*t <= (p_t(i+1)-n_t(i+1)) - (p_ti-n_ti) <= t_up*t
+//
+// A generator starting up may ramp to XpMin beyond the limit, and one shutting down may
+// ramp down from XpMin beyond it, so the row of a generator is of the form:
+// -t_down*t <= (p_t(i+1)-n_t(i+1)) - (p_ti-n_ti) - XpMin*su_t(i+1) + XpMin*sd_t(i+1) <= t_up*t
+//
+// Units without a real positive or real negative power column, such as loads, ramp their
+// net power over the columns they have.
+//
+// t_up, t_down: Maximum rate of increase and decrease of real power (per hour, positive)
+func RampRateConstraints(t_se *Series, t_pid uuid.UUID, t_up float64, t_down float64, t_tstep float64) []SparseConstraint {
+	cx := make([]SparseConstraint, 0)
+	for i := 0; i < len(t_se.clusters)-1; i++ {
+		c := NewSparseConstraint(-t_down*t_tstep, t_up*t_tstep).Named(fmt.Sprintf("%v.ramp.t%v", t_pid, i+1))
+		t_se.addNetPower(&c, t_pid, i+1, 1)
+		t_se.addNetPower(&c, t_pid, i, -1)
+		t_se.addStartStopAllowance(&c, t_pid, i+1)
+		if len(c.Index) > 0 {
+			cx = append(cx, c)
+		}
+	}
+
+	return cx
+}
+
+// InitialRampRateConstraint returns a constraint of the form:
+// -t_down*t <= (p_t0-n_t0) - t_x <= t_up*t, where t_x is the measured real power of the
+// unit before the first time step. A generator has the start up and shut down allowance
+// of RampRateConstraints at the first time step. If the unit has no power columns at the
+// first time step the constraint is unbounded.
+func InitialRampRateConstraint(t_se *Series, t_pid uuid.UUID, t_up float64, t_down float64, t_tstep float64, t_x float64) SparseConstraint {
+	c := NewSparseConstraint(t_x-t_down*t_tstep, t_x+t_up*t_tstep).Named(t_pid.String() + ".initial_ramp")
+	if len(t_se.clusters) > 0 {
+		t_se.addNetPower(&c, t_pid, 0, 1)
+		t_se.addStartStopAllowance(&c, t_pid, 0)
+	}
+	if len(c.Index) == 0 {
+		c.Lb, c.Ub = math.Inf(-1), math.Inf(1)
+	}
+
+	return c
+}
+
+// addStartStopAllowance adds -XpMin*su + XpMin*sd of generator t_pid at the t_k-th time
+// step to t_c. Units without a minimum output add nothing.
+func (se Series) addStartStopAllowance(t_c *SparseConstraint, t_pid uuid.UUID, t_k int) {
+	xpMin := make([]float64, 0)
+	loc := se.stepLocator(t_k)
+	suLoc := loc(t_pid, func(u Unit) []int {
+		mu, ok := u.(minimumOutputer)
+		if !ok {
+			return []int{}
+		}
+		for range mu.StartUpLoc() {
+			xpMin = append(xpMin, mu.MinimumOutput())
+		}
+		return mu.StartUpLoc()
+	})
+	sdLoc := loc(t_pid, func(u Unit) []int {
+		if _, ok := u.(minimumOutputer); !ok {
+			return []int{}
+		}
+		return shutDownLoc(u)
+	})
+	for k, i := range suLoc {
+		if xpMin[k] != 0 {
+			t_c.Add(i, -xpMin[k])
+			t_c.Add(sdLoc[k], xpMin[k])
+		}
+	}
+}
+
+// addNetPower adds t_sign times the net real power, p - n, of unit t_pid at the t_k-th time
+// step to t_c.
+func (se Series) addNetPower(t_c *SparseConstraint, t_pid uuid.UUID, t_k int, t_sign float64) {
+	loc := se.stepLocator(t_k)
+	for _, i := range loc(t_pid, realPositivePowerLoc) {
+		t_c.Add(i, t_sign)
+	}
+	for _, i := range loc(t_pid, realNegativePowerLoc) {
+		t_c.Add(i, -t_sign)
+	}
+}
@@ -70,7 +70,7 @@ func TestSeriesBatteryEnergyConstraint(t *testing.T) {
 	bec := BatteryEnergyConstraint(&s, pid1, 1)
 	for _, c := range bec {
 		//fmt.Println(bec)
-		err := s.NewConstraint(c)
+		err := s.NewSparseConstraint(c)
 		assert.Nil(t, err)
 	}
 
@@ -78,3 +78,238 @@ func TestSeriesBatteryEnergyConstraint(t *testing.T) {
 	assert.Equal(t, []float64{0, -1, 1, 0, 1, 0, 0, 0, 0, 0, 0, 0, -1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}, sec[0])
 	assert.Equal(t, []float64{0, 0, 0, 0, 0, 0, 0, 0, 0, -1, 1, 0, 1, 0, 0, 0, 0, 0, 0, 0, -1, 0, 0, 0, 0, 0}, sec[1])
 }
+
+func TestSeriesBatteryEnergyEfficiencyConstraint(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	inf := math.Inf(1)
+	a1 := NewBasicUnit(pid1, 1, 2, 3, 4, inf, inf, inf, inf)
+	g := NewGroup(a1)
+	cl := NewCluster(g)
+	s := NewSeries(cl, cl, cl)
+
+	bec, err := BatteryEnergyEfficiencyConstraint(&s, pid1, 0.5, 0.9, 0.8, 0.01)
+	assert.Nil(t, err)
+	assert.Len(t, bec, 2)
+	assert.Equal(t, []float64{0, 0, 0, 0, 0, -0.625, 0.45, 0, 0.99, 0, 0, 0, -1, 0}, bec[1].Dense(s.ColumnSize()))
+
+	bec, err = BatteryEnergyEfficiencyConstraint(&s, pid1, 0.5, 1, 1, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, BatteryEnergyConstraint(&s, pid1, 0.5), bec)
+
+	// efficiencies outside of (0, 1] and self discharge outside of [0, 1) are rejected
+	for _, l := range [][3]float64{{0, 1, 0}, {1, 0, 0}, {1.1, 1, 0}, {1, 1, -0.1}, {1, 1, 1}} {
+		_, err = BatteryEnergyEfficiencyConstraint(&s, pid1, 0.5, l[0], l[1], l[2])
+		assert.Error(t, err, "losses accepted: %v", l)
+		_, err = BatteryFinalEnergyConstraint(&s, pid1, 0.5, l[0], l[1], l[2])
+		assert.Error(t, err, "losses accepted: %v", l)
+	}
+}
+
+func TestSeriesNames(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	inf := math.Inf(1)
+	a1 := NewBasicUnit(pid1, 1, 2, 3, 4, inf, inf, inf, inf)
+	g := NewGroup(a1)
+	err := g.NewSparseConstraint(NetLoadConstraint(&g, 1))
+	assert.Nil(t, err)
+
+	cl := NewCluster(g)
+	s := NewSeries(cl, cl)
+	err = s.NewSparseConstraint(BatteryEnergyConstraint(&s, pid1, 1)...)
+	assert.Nil(t, err)
+
+	p := pid1.String()
+	cn := s.ColumnNames()
+	assert.Len(t, cn, s.ColumnSize())
+	assert.Equal(t, "t0.g0."+p+".xp", cn[0])
+	assert.Equal(t, "t1.g0."+p+".xe", cn[7])
+
+	assert.Equal(t, []string{"t0.g0.net_load", "t1.g0.net_load", p + ".energy_balance.t0"}, s.ConstraintNames())
+}
+
+func TestSeriesBatteryTerminalEnergyConstraints(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	inf := math.Inf(1)
+	a1 := NewBasicUnit(pid1, 1, 2, 3, 4, inf, inf, inf, inf)
+	g := NewGroup(a1)
+	cl := NewCluster(g)
+	s := NewSeries(cl, cl, cl)
+
+	// the final step's charge and discharge count against the stored energy left after it
+	tc, err := BatteryTerminalEnergyConstraint(&s, pid1, 0.5, 1, 1, 0, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{5, 0, 0, 0, 0, 0, 0, 0, 0, -0.5, 0.5, 0, 1, 5}, tc.Dense(s.ColumnSize()))
+
+	mc, err := BatteryMinimumTerminalEnergyConstraint(&s, pid1, 0.5, 0.9, 0.8, 0.01, 5)
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{5, 0, 0, 0, 0, 0, 0, 0, 0, -0.625, 0.45, 0, 0.99, inf}, mc.Dense(s.ColumnSize()))
+
+	cc, err := BatteryCyclicEnergyConstraint(&s, pid1, 0.5, 1, 1, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{0, 0, 0, 0, -1, 0, 0, 0, 0, -0.5, 0.5, 0, 1, 0}, cc.Dense(s.ColumnSize()))
+
+	fc, err := BatteryFinalEnergyConstraint(&s, pid1, 0.5, 1, 1, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{0, 0, 0, 0, 0, 0, 0, 0, 0, -0.5, 0.5, 0, 1, inf}, fc.Dense(s.ColumnSize()))
+	assert.Equal(t, pid1.String()+".final_energy", fc.Name)
+}
+
+func TestSeriesBatteryTerminalEnergySolve(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	pid2, _ := uuid.NewUUID()
+	inf := math.Inf(1)
+	a1 := NewBasicUnit(pid1, 0.1, 0.1, 0, 0, 10, 10, 0, 10)
+	a2 := NewBasicUnit(pid2, 1, 0, 0, 0, inf, 0, 0, 0)
+	g := NewGroup(a1, a2)
+	err := g.NewSparseConstraint(NetLoadConstraint(&g, 6))
+	assert.Nil(t, err)
+	cl := NewCluster(g)
+
+	// the battery must end with the energy it starts with, so it cannot serve the load
+	s := NewSeries(&cl, &cl, &cl)
+	err = s.NewSparseConstraint(BatteryEnergyConstraint(&s, pid1, 1)...)
+	assert.Nil(t, err)
+	err = s.NewSparseConstraint(BatteryInitialEnergyConstraint(&s, pid1, 5))
+	assert.Nil(t, err)
+	tc, err := BatteryTerminalEnergyConstraint(&s, pid1, 1, 1, 1, 0, 5)
+	assert.Nil(t, err)
+	err = s.NewSparseConstraint(tc)
+	assert.Nil(t, err)
+
+	res, err := NativeSolver{}.SolveLp(s, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 18, res.Objective, 1e-6)
+	for _, i := range s.RealPositivePowerPidLoc(pid1) {
+		assert.InDelta(t, 0, res.Columns[i], 1e-6)
+	}
+}
+
+func TestSeriesBatteryEnergyWindowConstraints(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	inf := math.Inf(1)
+	a1 := NewBasicUnit(pid1, 1, 2, 3, 4, inf, inf, inf, inf)
+	g := NewGroup(a1)
+	cl := NewCluster(g)
+	s := NewSeries(cl, cl)
+
+	wc, err := BatteryEnergyWindowConstraints(&s, pid1, []float64{1, 2}, []float64{8, 9})
+	assert.Nil(t, err)
+	assert.Len(t, wc, 2)
+	assert.Equal(t, []float64{2, 0, 0, 0, 0, 0, 0, 0, 1, 9}, wc[1].Dense(s.ColumnSize()))
+
+	wc, err = BatteryEnergyWindowConstraints(&s, pid1, []float64{1}, []float64{8, 9})
+	assert.Error(t, err)
+	assert.Empty(t, wc)
+
+	rc, err := BatteryReserveConstraints(&s, pid1, 1, 1, 1, 0, 3)
+	assert.Nil(t, err)
+	assert.Len(t, rc, 3)
+	assert.Equal(t, []float64{3, 0, 0, 0, 1, 0, 0, 0, 0, inf}, rc[0].Dense(s.ColumnSize()))
+	assert.Equal(t, []float64{3, 0, 0, 0, 0, -1, 1, 0, 1, inf}, rc[2].Dense(s.ColumnSize()))
+}
+
+func TestSeriesRampRateConstraints(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	inf := math.Inf(1)
+	a1 := NewBasicUnit(pid1, 1, 2, 3, 4, inf, inf, inf, inf)
+	g := NewGroup(a1)
+	cl := NewCluster(g)
+	s := NewSeries(cl, cl, cl)
+
+	rc := RampRateConstraints(&s, pid1, 4, 2, 0.5)
+	assert.Len(t, rc, 2)
+	assert.Equal(t, []float64{-1, 0, 0, 0, 0, -1, 1, 0, 0, 1, -1, 0, 0, 2}, rc[1].Dense(s.ColumnSize()))
+	assert.Equal(t, pid1.String()+".ramp.t2", rc[1].Name)
+
+	ic := InitialRampRateConstraint(&s, pid1, 4, 2, 0.5, 3)
+	assert.Equal(t, []float64{2, 1, -1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 5}, ic.Dense(s.ColumnSize()))
+}
+
+func TestSeriesRampRateSolve(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	pid2, _ := uuid.NewUUID()
+	inf := math.Inf(1)
+	gen := NewBasicUnit(pid1, 1, 0, 0, 0, 20, 0, 0, 0)
+	grid := NewBasicUnit(pid2, 10, 0, 0, 0, inf, 0, 0, 0)
+
+	clx := make([]Sequencer, 0)
+	for _, nl := range []float64{0, 20, 0} {
+		g := NewGroup(gen, grid)
+		err := g.NewSparseConstraint(NetLoadConstraint(&g, nl))
+		assert.Nil(t, err)
+		clx = append(clx, g)
+	}
+	s := NewSeries(clx...)
+	err := s.NewSparseConstraint(RampRateConstraints(&s, pid1, 10, 5, 1)...)
+	assert.Nil(t, err)
+
+	res, err := NativeSolver{}.SolveLp(s, SolverOptions{})
+	assert.Nil(t, err)
+	srx, err := s.Decode(res.Columns)
+	assert.Nil(t, err)
+
+	ux := UnitSeries(srx, pid1)
+	assert.InDelta(t, 5, ux[1].RealPositivePower, 1e-6, "ramp down limit not applied")
+	assert.InDelta(t, 15, UnitSeries(srx, pid2)[1].RealPositivePower, 1e-6)
+}
+
+func TestNewSeriesFromTemplate(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	a1 := NewBasicUnit(pid1, 1, 2, 3, 4, 10, 10, 10, 10)
+	g := NewGroup(a1)
+	cl := NewCluster(g)
+	s := NewSeriesFromTemplate(cl, 3)
+
+	assert.Equal(t, NewSeries(cl, cl, cl).CostCoefficients(), s.CostCoefficients())
+	assert.Equal(t, 3*cl.ColumnSize(), s.ColumnSize())
+
+	pLoc := s.RealPositivePowerPidLoc(pid1)
+	err := s.SetCostProfile(pLoc, []float64{1, 5, 9})
+	assert.Nil(t, err)
+	err = s.SetUpperBoundProfile(pLoc, []float64{10, 0, 4})
+	assert.Nil(t, err)
+
+	cc := s.CostCoefficients()
+	b := s.Bounds()
+	assert.Equal(t, []float64{1, 5, 9}, []float64{cc[pLoc[0]], cc[pLoc[1]], cc[pLoc[2]]})
+	assert.Equal(t, [2]float64{0, 4}, b[pLoc[2]])
+	assert.Equal(t, []float64{1, 2, 3, 4}, cl.CostCoefficients(), "template modified")
+	assert.Equal(t, [2]float64{0, 10}, cl.Bounds()[0], "template modified")
+
+	assert.Error(t, s.SetCostProfile(pLoc, []float64{1, 2}))
+	assert.Error(t, s.SetCostProfile([]int{s.ColumnSize()}, []float64{1}))
+	assert.Error(t, s.SetUpperBoundProfile(pLoc[:1], []float64{-1}))
+
+	// profiles set on a copy leave the original unchanged
+	c := s
+	assert.Nil(t, c.SetCostProfile(pLoc[:1], []float64{7}))
+	assert.Nil(t, c.SetUpperBoundProfile(pLoc[:1], []float64{2}))
+	assert.Nil(t, c.SetFixedProfile(pLoc[2:], []float64{3}))
+	c.SetEmissionFactor(pid1, EmissionFactor{Power: 1})
+	assert.Equal(t, 1.0, s.CostCoefficients()[pLoc[0]])
+	assert.Equal(t, [2]float64{0, 10}, s.Bounds()[pLoc[0]])
+	assert.Equal(t, [2]float64{0, 4}, s.Bounds()[pLoc[2]])
+	assert.Empty(t, s.emitters())
+	assert.Equal(t, 7.0, c.CostCoefficients()[pLoc[0]])
+}
+
+func TestSeriesProfileSolve(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	pid2, _ := uuid.NewUUID()
+	inf := math.Inf(1)
+	pv := NewBasicUnit(pid1, 0, 0, 0, 0, inf, 0, 0, 0)
+	grid := NewBasicUnit(pid2, 1, 0, 0, 0, inf, 0, 0, 0)
+	g := NewGroup(pv, grid)
+	err := g.NewSparseConstraint(NetLoadConstraint(&g, 10))
+	assert.Nil(t, err)
+
+	s := NewSeriesFromTemplate(g, 3)
+	err = s.SetUpperBoundProfile(s.RealPositivePowerPidLoc(pid1), []float64{0, 6, 12})
+	assert.Nil(t, err)
+	err = s.SetCostProfile(s.RealPositivePowerPidLoc(pid2), []float64{1, 2, 3})
+	assert.Nil(t, err)
+
+	res, err := NativeSolver{}.SolveLp(s, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 10*1+4*2+0*3, res.Objective, 1e-6)
+}
@@ -0,0 +1,460 @@
+package cgc_optimize
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	simplexPivotTol      = 1e-9  // smallest magnitude accepted as a pivot element
+	simplexOptimalityTol = 1e-9  // reduced cost magnitude treated as zero
+	simplexFeasibleTol   = 1e-7  // bound violation treated as feasible
+	simplexDegenerateMax = 50    // degenerate pivots before switching to Bland's rule
+	simplexIterationMax  = 50000 // pivots allowed per phase
+)
+
+// errSimplexLimit is returned when the simplex exhausts its iteration or time budget.
+var errSimplexLimit = errors.New("simplex: iteration or time limit reached")
+
+// simplexProblem is a linear program in the form solved by the native simplex:
+//
+// min c'x  s.t.  lb_i <= a_i'x <= ub_i,  lo_j <= x_j <= up_j
+type simplexProblem struct {
+	cost  []float64
+	lo    []float64
+	up    []float64
+	rowLb []float64
+	rowUb []float64
+	rows  [][]simplexEntry
+}
+
+// simplexEntry is a nonzero coefficient of a constraint row
+type simplexEntry struct {
+	col int
+	val float64
+}
+
+// simplexSolution is an optimal vertex of a simplexProblem
+type simplexSolution struct {
+	x         []float64
+	rows      []float64
+	duals     []float64
+	objective float64
+}
+
+// newSimplexProblem validates w and converts it to a simplexProblem.
+func newSimplexProblem(w LinearProgram) (simplexProblem, error) {
+	c := w.CostCoefficients()
+	b := w.Bounds()
+	if len(b) != len(c) {
+		err := fmt.Sprintf("linear program contains %v bounds, expected: %v", len(b), len(c))
+		return simplexProblem{}, errors.New(err)
+	}
+
+	p := simplexProblem{
+		cost: append([]float64{}, c...),
+		lo:   make([]float64, len(c)),
+		up:   make([]float64, len(c)),
+	}
+	for j, bnd := range b {
+		p.lo[j], p.up[j] = bnd[0], bnd[1]
+	}
+
+	for _, c := range SparseConstraintsOf(w) {
+		if err := c.validate(len(p.cost)); err != nil {
+			return simplexProblem{}, err
+		}
+		entries := make([]simplexEntry, 0)
+		for k, j := range c.Index {
+			if c.Value[k] != 0 {
+				entries = append(entries, simplexEntry{j, c.Value[k]})
+			}
+		}
+		p.rows = append(p.rows, entries)
+		p.rowLb = append(p.rowLb, c.Lb)
+		p.rowUb = append(p.rowUb, c.Ub)
+	}
+
+	return p, nil
+}
+
+// simplexTableau is the dense tableau of a bounded-variable primal simplex. Every row
+// is an equation sum_j(t_ij * x_j) = 0 in which the basic variable has coefficient 1.
+//
+// Columns are ordered: structural variables, one slack per row (s_i = a_i'x bounded by
+// the row bounds), then artificial variables used to find a first feasible basis.
+type simplexTableau struct {
+	t       [][]float64
+	d       []float64 // reduced costs
+	cost    []float64
+	x       []float64
+	lo      []float64
+	up      []float64
+	basis   []int // basic variable of each row
+	rowOf   []int // row of each basic variable, -1 when nonbasic
+	nStruct int
+	nArt    int
+
+	deadline time.Time
+}
+
+// solveSimplex returns an optimal solution of p, or an error wrapping the terminal status.
+// The structurals start from t_x0, clamped to their bounds, if it holds a value for each
+// of them.
+func solveSimplex(p simplexProblem, t_x0 []float64, t_deadline time.Time) (simplexSolution, Status, error) {
+	for j := range p.lo {
+		if p.lo[j] > p.up[j]+simplexFeasibleTol {
+			return simplexSolution{}, StatusInfeasible, SolveError{StatusInfeasible}
+		}
+	}
+	for i := range p.rowLb {
+		if p.rowLb[i] > p.rowUb[i]+simplexFeasibleTol {
+			return simplexSolution{}, StatusInfeasible, SolveError{StatusInfeasible}
+		}
+	}
+
+	tb := newSimplexTableau(p, t_x0, t_deadline)
+
+	// phase 1: minimize the sum of artificial variables
+	if tb.nArt > 0 {
+		phase1 := make([]float64, len(tb.x))
+		for j := len(tb.x) - tb.nArt; j < len(tb.x); j++ {
+			phase1[j] = 1
+		}
+		tb.setCost(phase1)
+		if status, err := tb.iterate(); err != nil {
+			return simplexSolution{}, status, err
+		}
+
+		var infeas float64
+		for j := len(tb.x) - tb.nArt; j < len(tb.x); j++ {
+			infeas += tb.x[j]
+		}
+		if infeas > simplexFeasibleTol*float64(1+len(p.rows)) {
+			return simplexSolution{}, StatusInfeasible, SolveError{StatusInfeasible}
+		}
+
+		// pin artificials at zero for the remainder of the solve
+		for j := len(tb.x) - tb.nArt; j < len(tb.x); j++ {
+			tb.up[j] = 0
+		}
+	}
+
+	// phase 2: minimize the objective
+	phase2 := make([]float64, len(tb.x))
+	copy(phase2, p.cost)
+	tb.setCost(phase2)
+	if status, err := tb.iterate(); err != nil {
+		return simplexSolution{}, status, err
+	}
+
+	m := len(p.rows)
+	sol := simplexSolution{
+		x:     append([]float64{}, tb.x[:tb.nStruct]...),
+		rows:  append([]float64{}, tb.x[tb.nStruct:tb.nStruct+m]...),
+		duals: make([]float64, m),
+	}
+	for i := 0; i < m; i++ {
+		if tb.rowOf[tb.nStruct+i] < 0 {
+			sol.duals[i] = tb.d[tb.nStruct+i]
+		}
+	}
+	for j, c := range p.cost {
+		sol.objective += c * sol.x[j]
+	}
+
+	return sol, StatusOptimal, nil
+}
+
+// newSimplexTableau returns a tableau with a slack or artificial basis for p, with the
+// structurals at t_x0 if it holds a value for each of them.
+func newSimplexTableau(p simplexProblem, t_x0 []float64, t_deadline time.Time) *simplexTableau {
+	n, m := len(p.cost), len(p.rows)
+
+	// place structurals at their starting values and measure the resulting row activities
+	xs := make([]float64, n)
+	for j := range xs {
+		xs[j] = initialValue(p.lo[j], p.up[j])
+		if len(t_x0) == n && !math.IsNaN(t_x0[j]) && !math.IsInf(t_x0[j], 0) {
+			xs[j] = math.Max(p.lo[j], math.Min(p.up[j], t_x0[j]))
+		}
+	}
+
+	act := make([]float64, m)
+	nArt := 0
+	for i, row := range p.rows {
+		for _, e := range row {
+			act[i] += e.val * xs[e.col]
+		}
+		if act[i] < p.rowLb[i]-simplexFeasibleTol || act[i] > p.rowUb[i]+simplexFeasibleTol {
+			nArt++
+		}
+	}
+
+	size := n + m + nArt
+	tb := &simplexTableau{
+		t:        make([][]float64, m),
+		d:        make([]float64, size),
+		cost:     make([]float64, size),
+		x:        make([]float64, size),
+		lo:       make([]float64, size),
+		up:       make([]float64, size),
+		basis:    make([]int, m),
+		rowOf:    make([]int, size),
+		nStruct:  n,
+		nArt:     nArt,
+		deadline: t_deadline,
+	}
+	for j := range tb.rowOf {
+		tb.rowOf[j] = -1
+	}
+	copy(tb.x, xs)
+	copy(tb.lo, p.lo)
+	copy(tb.up, p.up)
+
+	art := n + m
+	for i, row := range p.rows {
+		s := n + i
+		tb.lo[s], tb.up[s] = p.rowLb[i], p.rowUb[i]
+		tb.t[i] = make([]float64, size)
+
+		if act[i] >= p.rowLb[i]-simplexFeasibleTol && act[i] <= p.rowUb[i]+simplexFeasibleTol {
+			// s_i - a_i'x = 0 with s_i basic
+			for _, e := range row {
+				tb.t[i][e.col] -= e.val
+			}
+			tb.t[i][s] = 1
+			tb.x[s] = act[i]
+			tb.setBasic(i, s)
+			continue
+		}
+
+		// a_i'x - s_i + sigma*r_i = 0 with the artificial r_i basic and s_i at its
+		// violated bound
+		bnd := p.rowLb[i]
+		if act[i] > p.rowUb[i] {
+			bnd = p.rowUb[i]
+		}
+		sigma := 1.0
+		if bnd < act[i] {
+			sigma = -1.0
+		}
+		for _, e := range row {
+			tb.t[i][e.col] += e.val / sigma
+		}
+		tb.t[i][s] = -1 / sigma
+		tb.t[i][art] = 1
+		tb.x[s] = bnd
+		tb.lo[art], tb.up[art] = 0, math.Inf(1)
+		tb.x[art] = math.Abs(bnd - act[i])
+		tb.setBasic(i, art)
+		art++
+	}
+
+	return tb
+}
+
+// initialValue returns the starting value of a nonbasic variable bounded by [t_lo, t_up]
+func initialValue(t_lo float64, t_up float64) float64 {
+	switch {
+	case !math.IsInf(t_lo, 0):
+		return t_lo
+	case !math.IsInf(t_up, 0):
+		return t_up
+	default:
+		return 0
+	}
+}
+
+func (tb *simplexTableau) setBasic(t_row int, t_col int) {
+	tb.basis[t_row] = t_col
+	tb.rowOf[t_col] = t_row
+}
+
+// setCost replaces the objective and recomputes the reduced costs: d_j = c_j - sum_i(c_B(i) * t_ij)
+func (tb *simplexTableau) setCost(t_c []float64) {
+	tb.cost = t_c
+	copy(tb.d, t_c)
+	for i, row := range tb.t {
+		cb := t_c[tb.basis[i]]
+		if cb == 0 {
+			continue
+		}
+		for j, v := range row {
+			if v != 0 {
+				tb.d[j] -= cb * v
+			}
+		}
+	}
+	for _, b := range tb.basis {
+		tb.d[b] = 0
+	}
+}
+
+// iterate pivots until the current objective is optimal.
+func (tb *simplexTableau) iterate() (Status, error) {
+	degenerate := 0
+	for iter := 0; iter < simplexIterationMax; iter++ {
+		if !tb.deadline.IsZero() && iter%64 == 0 && time.Now().After(tb.deadline) {
+			return StatusLimit, errSimplexLimit
+		}
+
+		j, dir := tb.price(degenerate > simplexDegenerateMax)
+		if j < 0 {
+			return StatusOptimal, nil
+		}
+
+		step, leave := tb.ratio(j, dir, degenerate > simplexDegenerateMax)
+		if math.IsInf(step, 1) {
+			return StatusUnbounded, SolveError{StatusUnbounded}
+		}
+		if step <= simplexFeasibleTol {
+			degenerate++
+		} else {
+			degenerate = 0
+		}
+
+		// move the entering variable and update the basic variables
+		tb.x[j] += dir * step
+		for i, row := range tb.t {
+			if row[j] != 0 {
+				tb.x[tb.basis[i]] -= row[j] * dir * step
+			}
+		}
+
+		if leave < 0 {
+			continue // entering variable moved to its opposite bound
+		}
+		tb.pivot(leave, j)
+	}
+
+	return StatusLimit, errSimplexLimit
+}
+
+// price returns an entering variable and the direction it moves, or -1 at optimality.
+// Dantzig's rule is used unless t_bland is set, in which case the lowest eligible index
+// is returned to prevent cycling.
+func (tb *simplexTableau) price(t_bland bool) (int, float64) {
+	best, bestDir, bestScore := -1, 0.0, 0.0
+	for j, dj := range tb.d {
+		if tb.rowOf[j] >= 0 || tb.up[j]-tb.lo[j] <= simplexFeasibleTol {
+			continue
+		}
+
+		dir := 0.0
+		if dj < -simplexOptimalityTol && tb.x[j] < tb.up[j]-simplexFeasibleTol {
+			dir = 1
+		} else if dj > simplexOptimalityTol && tb.x[j] > tb.lo[j]+simplexFeasibleTol {
+			dir = -1
+		}
+		if dir == 0 {
+			continue
+		}
+		if t_bland {
+			return j, dir
+		}
+		if math.Abs(dj) > bestScore {
+			best, bestDir, bestScore = j, dir, math.Abs(dj)
+		}
+	}
+
+	return best, bestDir
+}
+
+// ratio returns the step the entering variable t_j can take in direction t_dir and the
+// row whose basic variable leaves the basis, -1 if the step is limited by t_j's own bound.
+// A warm started variable may begin between its bounds, so its own bound is measured from
+// its current value.
+func (tb *simplexTableau) ratio(t_j int, t_dir float64, t_bland bool) (float64, int) {
+	step := tb.up[t_j] - tb.x[t_j]
+	if t_dir < 0 {
+		step = tb.x[t_j] - tb.lo[t_j]
+	}
+	leave := -1
+
+	for i, row := range tb.t {
+		alpha := -row[t_j] * t_dir // rate of change of the basic variable
+		if math.Abs(alpha) <= simplexPivotTol {
+			continue
+		}
+
+		b := tb.basis[i]
+		var limit float64
+		if alpha > 0 {
+			if math.IsInf(tb.up[b], 1) {
+				continue
+			}
+			limit = (tb.up[b] - tb.x[b]) / alpha
+		} else {
+			if math.IsInf(tb.lo[b], -1) {
+				continue
+			}
+			limit = (tb.lo[b] - tb.x[b]) / alpha
+		}
+		if limit < 0 {
+			limit = 0
+		}
+
+		switch {
+		case limit < step-simplexPivotTol:
+			step, leave = limit, i
+		case limit <= step+simplexPivotTol && leave >= 0:
+			// break ties on the larger pivot, or the lower index under Bland's rule
+			if t_bland && b < tb.basis[leave] || !t_bland && math.Abs(row[t_j]) > math.Abs(tb.t[leave][t_j]) {
+				step, leave = math.Min(step, limit), i
+			}
+		}
+	}
+
+	return step, leave
+}
+
+// pivot makes t_j basic in row t_r.
+func (tb *simplexTableau) pivot(t_r int, t_j int) {
+	out := tb.basis[t_r]
+
+	// snap the leaving variable to the bound it reached
+	if math.Abs(tb.x[out]-tb.lo[out]) <= math.Abs(tb.x[out]-tb.up[out]) {
+		tb.x[out] = tb.lo[out]
+	} else {
+		tb.x[out] = tb.up[out]
+	}
+
+	pr := tb.t[t_r]
+	pv := pr[t_j]
+	for k := range pr {
+		if pr[k] != 0 {
+			pr[k] /= pv
+		}
+	}
+
+	nz := make([]int, 0)
+	for k, v := range pr {
+		if v != 0 {
+			nz = append(nz, k)
+		}
+	}
+
+	for i, row := range tb.t {
+		if i == t_r || row[t_j] == 0 {
+			continue
+		}
+		f := row[t_j]
+		for _, k := range nz {
+			row[k] -= f * pr[k]
+		}
+		row[t_j] = 0
+	}
+
+	if f := tb.d[t_j]; f != 0 {
+		for _, k := range nz {
+			tb.d[k] -= f * pr[k]
+		}
+		tb.d[t_j] = 0
+	}
+
+	tb.rowOf[out] = -1
+	tb.setBasic(t_r, t_j)
+}
@@ -0,0 +1,741 @@
+package cgc_optimize
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/google/uuid"
+	"gopkg.in/yaml.v3"
+)
+
+// Site is a declarative description of a site, read from JSON or YAML, from which the
+// dispatch Series is built.
+//
+// Horizon: Number of time steps in the series
+// TimeStep: Length of each time step (hours)
+// Units: Assets at the site
+// Groups: Buses, each balancing the net load of the units it contains
+// Links: PIDs of units connecting two groups, whose dispatch is shared by both
+// EmissionsCap: Limit on the total emissions of the units over the horizon, optional
+type Site struct {
+	Horizon      int         `json:"horizon" yaml:"horizon"`
+	TimeStep     float64     `json:"time_step" yaml:"time_step"`
+	Units        []SiteUnit  `json:"units" yaml:"units"`
+	Groups       []SiteGroup `json:"groups" yaml:"groups"`
+	Links        []uuid.UUID `json:"links" yaml:"links"`
+	EmissionsCap *float64    `json:"emissions_cap" yaml:"emissions_cap"`
+}
+
+// SiteUnit describes a single asset. Basic units take the eight parameters of
+// NewBasicUnit, with an omitted upper bound read as unbounded. Piecewise units take the
+// critical points of their cost curve.
+//
+// Type: "basic" (default), "piecewise", "generator", "grid", "renewable", "load" or
+// "storage". Generators
+// take Cp, Cc and XpUb (required) with their commitment. Grid units take Cp and XpUb for
+// import, and Cn and XnUb for export, with Cn negative when export is paid. Renewable
+// units take Cp, Cu and XpUb (required nameplate rating) with their availability. Load
+// units take Cs and XnUb (required demand limit) with their demand or deferrable energy.
+// Storage units take the parameters of basic units, except Ce, with their degradation.
+// CapacityConstraints: Limit real power to the real capacity of the unit
+// Direction: Forbid simultaneous positive and negative power, basic and grid units only.
+// The series must be solved as a MIP.
+// Ramp: Limit the change in real power between time steps
+// Storage: Track stored energy across the series, basic and storage units only
+// DemandCharge: Charge the peak import across the series, grid units only
+// Availability: Available power at each time step, renewable units only. The nameplate
+// rating is available if omitted.
+// Demand: Demand at each time step, load units only. Demand is XnUb if omitted.
+// Deferrable: Energy required within a window of time steps, load units only
+// Emissions: Emission factors of the unit, optional
+// Degradation: Wear cost of the unit, storage units only
+type SiteUnit struct {
+	PID  uuid.UUID `json:"pid" yaml:"pid"`
+	Type string    `json:"type" yaml:"type"`
+
+	Cp   float64  `json:"cp" yaml:"cp"`
+	Cn   float64  `json:"cn" yaml:"cn"`
+	Cc   float64  `json:"cc" yaml:"cc"`
+	Ce   float64  `json:"ce" yaml:"ce"`
+	Cu   float64  `json:"cu" yaml:"cu"`
+	Cs   float64  `json:"cs" yaml:"cs"`
+	XpUb *float64 `json:"xp_ub" yaml:"xp_ub"`
+	XnUb *float64 `json:"xn_ub" yaml:"xn_ub"`
+	XcUb *float64 `json:"xc_ub" yaml:"xc_ub"`
+	XeUb *float64 `json:"xe_ub" yaml:"xe_ub"`
+
+	Points     []SitePoint     `json:"points" yaml:"points"`
+	Commitment *SiteCommitment `json:"commitment" yaml:"commitment"`
+
+	CapacityConstraints bool         `json:"capacity_constraints" yaml:"capacity_constraints"`
+	Direction           bool         `json:"direction" yaml:"direction"`
+	Ramp                *SiteRamp    `json:"ramp" yaml:"ramp"`
+	Storage             *SiteStorage `json:"storage" yaml:"storage"`
+
+	DemandCharge *SiteDemandCharge `json:"demand_charge" yaml:"demand_charge"`
+	Availability []float64         `json:"availability" yaml:"availability"`
+	Demand       []float64         `json:"demand" yaml:"demand"`
+	Deferrable   *SiteDeferrable   `json:"deferrable" yaml:"deferrable"`
+	Emissions    *SiteEmissions    `json:"emissions" yaml:"emissions"`
+	Degradation  *SiteDegradation  `json:"degradation" yaml:"degradation"`
+}
+
+// SitePoint is a critical point of a piecewise unit cost curve.
+type SitePoint struct {
+	Value float64 `json:"value" yaml:"value"`
+	Cost  float64 `json:"cost" yaml:"cost"`
+}
+
+// SiteRamp describes the ramp rate limits of a unit.
+//
+// Up: Maximum increase in real power per hour, unbounded if omitted
+// Down: Maximum decrease in real power per hour, unbounded if omitted
+// InitialOutput: Measured real power before the first time step, optional
+type SiteRamp struct {
+	Up            *float64 `json:"up" yaml:"up"`
+	Down          *float64 `json:"down" yaml:"down"`
+	InitialOutput *float64 `json:"initial_output" yaml:"initial_output"`
+}
+
+// SiteDemandCharge describes the peak demand charge of a grid unit.
+//
+// Price: Cost of each unit of peak import
+// Peak: Peak import already set in the billing period
+type SiteDemandCharge struct {
+	Price float64 `json:"price" yaml:"price"`
+	Peak  float64 `json:"peak" yaml:"peak"`
+}
+
+// SiteDeferrable describes the energy required by a deferrable load.
+//
+// Energy: Energy served within the window
+// First: First time step of the window
+// Last: Last time step of the window
+type SiteDeferrable struct {
+	Energy float64 `json:"energy" yaml:"energy"`
+	First  int     `json:"first" yaml:"first"`
+	Last   int     `json:"last" yaml:"last"`
+}
+
+// SiteEmissions describes the emission factors of a unit.
+//
+// Energy: Emissions per unit of energy of real positive power
+// StartUp: Emissions of each start up, generator units only
+type SiteEmissions struct {
+	Energy  float64 `json:"energy" yaml:"energy"`
+	StartUp float64 `json:"start_up" yaml:"start_up"`
+}
+
+// SiteDegradation describes the wear cost of a storage unit.
+//
+// Throughput: Cost of each unit of real positive and real negative power
+// Depth: Critical points of the cost of a cycle by its depth of discharge, the stored
+// energy below xe_ub, optional. Requires storage.
+type SiteDegradation struct {
+	Throughput float64     `json:"throughput" yaml:"throughput"`
+	Depth      []SitePoint `json:"depth" yaml:"depth"`
+}
+
+// SiteCommitment describes the on/off commitment of a generator unit.
+//
+// MinOutput: Minimum stable real positive power while online
+// NoLoadCost: Cost of being online for a time step
+// StartUpCost: Cost of each start up
+// ShutDownCost: Cost of each shut down
+// MinUp: Minimum time steps online after a start up
+// MinDown: Minimum time steps offline after a shut down
+// InitialOnline: Online before the first time step, free if omitted
+// InitialSteps: Time steps online, or offline, before the first time step, requires
+// InitialOnline. The minimum up or down time is complete if omitted.
+type SiteCommitment struct {
+	MinOutput     float64 `json:"min_output" yaml:"min_output"`
+	NoLoadCost    float64 `json:"no_load_cost" yaml:"no_load_cost"`
+	StartUpCost   float64 `json:"start_up_cost" yaml:"start_up_cost"`
+	ShutDownCost  float64 `json:"shut_down_cost" yaml:"shut_down_cost"`
+	MinUp         int     `json:"min_up" yaml:"min_up"`
+	MinDown       int     `json:"min_down" yaml:"min_down"`
+	InitialOnline *bool   `json:"initial_online" yaml:"initial_online"`
+	InitialSteps  *int    `json:"initial_steps" yaml:"initial_steps"`
+}
+
+// SiteStorage describes the stored energy of a unit.
+//
+// InitialEnergy: Stored energy at the first time step, free if omitted
+// ChargeEfficiency: Fraction of charging energy stored, 1 if omitted
+// DischargeEfficiency: Fraction of discharged stored energy delivered, 1 if omitted
+// SelfDischarge: Fraction of stored energy lost each time step
+// TerminalEnergy: Stored energy at the final time step, free if omitted
+// MinTerminalEnergy: Minimum stored energy at the final time step, optional
+// Cyclic: End the series with the stored energy it started with
+// Reserve: Minimum stored energy at every time step
+type SiteStorage struct {
+	InitialEnergy       *float64 `json:"initial_energy" yaml:"initial_energy"`
+	ChargeEfficiency    *float64 `json:"charge_efficiency" yaml:"charge_efficiency"`
+	DischargeEfficiency *float64 `json:"discharge_efficiency" yaml:"discharge_efficiency"`
+	SelfDischarge       float64  `json:"self_discharge" yaml:"self_discharge"`
+	TerminalEnergy      *float64 `json:"terminal_energy" yaml:"terminal_energy"`
+	MinTerminalEnergy   *float64 `json:"min_terminal_energy" yaml:"min_terminal_energy"`
+	Cyclic              bool     `json:"cyclic" yaml:"cyclic"`
+	Reserve             float64  `json:"reserve" yaml:"reserve"`
+}
+
+// SiteGroup describes a bus. Forecasts hold one value per time step of the horizon; a
+// group without a net load forecast is not balanced.
+//
+// NetLoad: Net load to be served by the group at each time step
+// Capacity: Minimum real capacity of the group at each time step, optional
+type SiteGroup struct {
+	Units    []uuid.UUID `json:"units" yaml:"units"`
+	NetLoad  []float64   `json:"net_load" yaml:"net_load"`
+	Capacity []float64   `json:"capacity" yaml:"capacity"`
+}
+
+// ParseSiteJSON returns the site described by t_data. Unknown fields are an error.
+func ParseSiteJSON(t_data []byte) (Site, error) {
+	site := Site{}
+	d := json.NewDecoder(bytes.NewReader(t_data))
+	d.DisallowUnknownFields()
+	if err := d.Decode(&site); err != nil {
+		return Site{}, err
+	}
+	return site, nil
+}
+
+// ParseSiteYAML returns the site described by t_data. Unknown fields are an error.
+func ParseSiteYAML(t_data []byte) (Site, error) {
+	site := Site{}
+	d := yaml.NewDecoder(bytes.NewReader(t_data))
+	d.KnownFields(true)
+	if err := d.Decode(&site); err != nil {
+		return Site{}, err
+	}
+	return site, nil
+}
+
+// LoadSite reads the site description at t_path, as JSON or YAML by file extension, and
+// returns the Series it describes.
+func LoadSite(t_path string) (Series, error) {
+	data, err := ioutil.ReadFile(t_path)
+	if err != nil {
+		return Series{}, err
+	}
+
+	var site Site
+	switch strings.ToLower(filepath.Ext(t_path)) {
+	case ".json":
+		site, err = ParseSiteJSON(data)
+	case ".yaml", ".yml":
+		site, err = ParseSiteYAML(data)
+	default:
+		err := fmt.Sprintf("unknown site file extension: %v", filepath.Ext(t_path))
+		return Series{}, errors.New(err)
+	}
+	if err != nil {
+		return Series{}, err
+	}
+
+	return NewSiteSeries(site)
+}
+
+// NewSiteSeries returns the Series described by t_site, with a cluster of the site groups
+// at each time step. Net load, capacity, linked bus, capacity, stored energy and emissions
+// constraints are applied as described.
+func NewSiteSeries(t_site Site) (Series, error) {
+	if err := t_site.validate(); err != nil {
+		return Series{}, err
+	}
+
+	units := make(map[uuid.UUID]Unit)
+	for _, su := range t_site.Units {
+		u, err := su.unit(t_site.TimeStep)
+		if err != nil {
+			return Series{}, err
+		}
+		units[su.PID] = u
+	}
+
+	clusters := make([]Sequencer, 0)
+	for t := 0; t < t_site.Horizon; t++ {
+		groups := make([]Group, 0)
+		for _, sg := range t_site.Groups {
+			ux := make([]Unit, 0)
+			for _, pid := range sg.Units {
+				ux = append(ux, units[pid])
+			}
+
+			g := NewGroup(ux...)
+			if len(sg.NetLoad) > 0 {
+				if err := g.NewSparseConstraint(NetLoadConstraint(&g, sg.NetLoad[t])); err != nil {
+					return Series{}, err
+				}
+			}
+			if len(sg.Capacity) > 0 {
+				if err := g.NewSparseConstraint(GroupPositiveCapacityConstraint(&g, sg.Capacity[t])); err != nil {
+					return Series{}, err
+				}
+			}
+			groups = append(groups, g)
+		}
+
+		cl := NewCluster(groups...)
+		for _, pid := range t_site.Links {
+			if err := cl.LinkBus(pid); err != nil {
+				return Series{}, err
+			}
+		}
+		clusters = append(clusters, cl)
+	}
+
+	se := NewSeries(clusters...)
+	for _, su := range t_site.Units {
+		if su.Ramp != nil {
+			up, down := upperBound(su.Ramp.Up), upperBound(su.Ramp.Down)
+			if err := se.NewSparseConstraint(RampRateConstraints(&se, su.PID, up, down, t_site.TimeStep)...); err != nil {
+				return Series{}, err
+			}
+			if su.Ramp.InitialOutput != nil {
+				if err := se.NewSparseConstraint(InitialRampRateConstraint(&se, su.PID, up, down, t_site.TimeStep, *su.Ramp.InitialOutput)); err != nil {
+					return Series{}, err
+				}
+			}
+		}
+
+		if len(su.Availability) > 0 {
+			if err := se.SetFixedProfile(se.AvailablePidLoc(su.PID), su.Availability); err != nil {
+				return Series{}, err
+			}
+		}
+
+		if len(su.Demand) > 0 {
+			if err := se.SetFixedProfile(se.DemandPidLoc(su.PID), su.Demand); err != nil {
+				return Series{}, err
+			}
+		}
+		if su.Deferrable != nil {
+			d := su.Deferrable
+			dx, err := LoadDeferrableConstraints(&se, su.PID, d.First, d.Last, d.Energy, t_site.TimeStep)
+			if err != nil {
+				return Series{}, err
+			}
+			if err := se.NewSparseConstraint(dx...); err != nil {
+				return Series{}, err
+			}
+		}
+
+		if su.DemandCharge != nil {
+			peak := se.NewUnitAuxiliaryColumn(su.PID, "peak", su.DemandCharge.Price, su.DemandCharge.Peak, math.Inf(1))
+			if err := se.NewSparseConstraint(GridDemandChargeConstraints(&se, su.PID, peak)...); err != nil {
+				return Series{}, err
+			}
+		}
+
+		if su.Type == "generator" {
+			sc := SiteCommitment{}
+			if su.Commitment != nil {
+				sc = *su.Commitment
+			}
+			if err := se.NewSparseConstraint(GeneratorTransitionConstraints(&se, su.PID)...); err != nil {
+				return Series{}, err
+			}
+			if sc.InitialOnline != nil {
+				if err := se.NewSparseConstraint(GeneratorInitialStatusConstraint(&se, su.PID, *sc.InitialOnline)); err != nil {
+					return Series{}, err
+				}
+			}
+			if sc.MinUp > 1 {
+				if err := se.NewSparseConstraint(GeneratorMinimumUpTimeConstraints(&se, su.PID, sc.MinUp)...); err != nil {
+					return Series{}, err
+				}
+			}
+			if sc.MinDown > 1 {
+				if err := se.NewSparseConstraint(GeneratorMinimumDownTimeConstraints(&se, su.PID, sc.MinDown)...); err != nil {
+					return Series{}, err
+				}
+			}
+			if sc.InitialOnline != nil && sc.InitialSteps != nil {
+				if *sc.InitialOnline {
+					if err := se.NewSparseConstraint(GeneratorInitialUpTimeConstraints(&se, su.PID, sc.MinUp, *sc.InitialSteps)...); err != nil {
+						return Series{}, err
+					}
+				} else {
+					if err := se.NewSparseConstraint(GeneratorInitialDownTimeConstraints(&se, su.PID, sc.MinDown, *sc.InitialSteps)...); err != nil {
+						return Series{}, err
+					}
+				}
+			}
+		}
+
+		if su.Storage != nil {
+			if err := su.Storage.apply(&se, su.PID, t_site.TimeStep); err != nil {
+				return Series{}, err
+			}
+		}
+
+		if su.Emissions != nil {
+			se.SetEmissionFactor(su.PID, EmissionFactor{su.Emissions.Energy * t_site.TimeStep, su.Emissions.StartUp})
+		}
+	}
+
+	if t_site.EmissionsCap != nil {
+		if err := se.NewSparseConstraint(EmissionsCapConstraint(&se, *t_site.EmissionsCap)); err != nil {
+			return Series{}, err
+		}
+	}
+
+	return se, nil
+}
+
+// apply adds the stored energy constraints of unit t_pid to t_se, with time steps of
+// t_tstep hours, and the energy balance of its depth segments if it has a depth cost.
+func (ss SiteStorage) apply(t_se *Series, t_pid uuid.UUID, t_tstep float64) error {
+	etaC, etaD := efficiency(ss.ChargeEfficiency), efficiency(ss.DischargeEfficiency)
+	sd := ss.SelfDischarge
+
+	cx, err := BatteryEnergyEfficiencyConstraint(t_se, t_pid, t_tstep, etaC, etaD, sd)
+	if err != nil {
+		return err
+	}
+
+	// each of the final energy constraints is checked as it is built
+	final := func(t_c SparseConstraint, t_err error) {
+		if t_err != nil && err == nil {
+			err = t_err
+		}
+		cx = append(cx, t_c)
+	}
+	final(BatteryFinalEnergyConstraint(t_se, t_pid, t_tstep, etaC, etaD, sd))
+	if ss.InitialEnergy != nil {
+		cx = append(cx, BatteryInitialEnergyConstraint(t_se, t_pid, *ss.InitialEnergy))
+	}
+	if ss.TerminalEnergy != nil {
+		final(BatteryTerminalEnergyConstraint(t_se, t_pid, t_tstep, etaC, etaD, sd, *ss.TerminalEnergy))
+	}
+	if ss.MinTerminalEnergy != nil {
+		final(BatteryMinimumTerminalEnergyConstraint(t_se, t_pid, t_tstep, etaC, etaD, sd, *ss.MinTerminalEnergy))
+	}
+	if ss.Cyclic {
+		final(BatteryCyclicEnergyConstraint(t_se, t_pid, t_tstep, etaC, etaD, sd))
+	}
+	if err != nil {
+		return err
+	}
+	if ss.Reserve > 0 {
+		rx, err := BatteryReserveConstraints(t_se, t_pid, t_tstep, etaC, etaD, sd, ss.Reserve)
+		if err != nil {
+			return err
+		}
+		cx = append(cx, rx...)
+	}
+	if len(t_se.PidLoc(t_pid, depthEnergyLoc)) > 0 {
+		dx, err := StorageDepthEnergyConstraints(t_se, t_pid, t_tstep, etaC, etaD, sd)
+		if err != nil {
+			return err
+		}
+		cx = append(cx, dx...)
+	}
+	return t_se.NewSparseConstraint(cx...)
+}
+
+// validate returns an error if the efficiencies of storage unit t_pid are out of range.
+func (ss SiteStorage) validate(t_pid uuid.UUID) error {
+	for _, eta := range []*float64{ss.ChargeEfficiency, ss.DischargeEfficiency} {
+		if eta != nil && (*eta <= 0 || *eta > 1) {
+			err := fmt.Sprintf("storage unit %v efficiency outside of (0, 1]", t_pid)
+			return errors.New(err)
+		}
+	}
+	if ss.SelfDischarge < 0 || ss.SelfDischarge >= 1 {
+		err := fmt.Sprintf("storage unit %v self discharge outside of [0, 1)", t_pid)
+		return errors.New(err)
+	}
+	return nil
+}
+
+// validate returns an error if the site description is incomplete or inconsistent.
+func (s Site) validate() error {
+	if s.Horizon < 1 {
+		err := fmt.Sprintf("site horizon is %v, expected at least 1 time step", s.Horizon)
+		return errors.New(err)
+	}
+	if s.TimeStep <= 0 {
+		err := fmt.Sprintf("site time step is %v, expected a positive duration", s.TimeStep)
+		return errors.New(err)
+	}
+
+	declared := make(map[uuid.UUID]SiteUnit)
+	for _, su := range s.Units {
+		if _, ok := declared[su.PID]; ok {
+			err := fmt.Sprintf("unit %v is declared more than once", su.PID)
+			return errors.New(err)
+		}
+		declared[su.PID] = su
+	}
+
+	membership := make(map[uuid.UUID]int)
+	for k, sg := range s.Groups {
+		inGroup := make(map[uuid.UUID]bool)
+		for _, pid := range sg.Units {
+			if _, ok := declared[pid]; !ok {
+				err := fmt.Sprintf("group %v references undeclared unit: %v", k, pid)
+				return errors.New(err)
+			}
+			if inGroup[pid] {
+				err := fmt.Sprintf("group %v contains unit %v more than once", k, pid)
+				return errors.New(err)
+			}
+			inGroup[pid] = true
+			membership[pid]++
+		}
+		if len(sg.NetLoad) > 0 && len(sg.NetLoad) != s.Horizon {
+			err := fmt.Sprintf("group %v net load forecast contains %v values, expected: %v", k, len(sg.NetLoad), s.Horizon)
+			return errors.New(err)
+		}
+		if len(sg.Capacity) > 0 && len(sg.Capacity) != s.Horizon {
+			err := fmt.Sprintf("group %v capacity forecast contains %v values, expected: %v", k, len(sg.Capacity), s.Horizon)
+			return errors.New(err)
+		}
+	}
+
+	linked := make(map[uuid.UUID]bool)
+	for _, pid := range s.Links {
+		if membership[pid] != 2 || linked[pid] {
+			err := fmt.Sprintf("linked unit %v is in %v groups, expected: 2", pid, membership[pid])
+			return errors.New(err)
+		}
+		linked[pid] = true
+	}
+
+	for _, su := range s.Units {
+		switch {
+		case len(su.Availability) > 0 && len(su.Availability) != s.Horizon:
+			err := fmt.Sprintf("unit %v availability forecast contains %v values, expected: %v", su.PID, len(su.Availability), s.Horizon)
+			return errors.New(err)
+		case len(su.Demand) > 0 && len(su.Demand) != s.Horizon:
+			err := fmt.Sprintf("unit %v demand forecast contains %v values, expected: %v", su.PID, len(su.Demand), s.Horizon)
+			return errors.New(err)
+		case su.Deferrable != nil && (su.Deferrable.First < 0 || su.Deferrable.Last >= s.Horizon || su.Deferrable.First > su.Deferrable.Last):
+			err := fmt.Sprintf("unit %v deferrable window [%v, %v] outside of the horizon", su.PID, su.Deferrable.First, su.Deferrable.Last)
+			return errors.New(err)
+		case membership[su.PID] == 0:
+			err := fmt.Sprintf("unit %v is not in a group", su.PID)
+			return errors.New(err)
+		case membership[su.PID] > 1 && !linked[su.PID]:
+			err := fmt.Sprintf("unit %v is in %v groups but is not linked", su.PID, membership[su.PID])
+			return errors.New(err)
+		case (su.Storage != nil || su.Ramp != nil || su.DemandCharge != nil || su.Type == "generator") && linked[su.PID]:
+			err := fmt.Sprintf("storage, ramp limited, demand charged or generator unit %v cannot be linked", su.PID)
+			return errors.New(err)
+		}
+
+		if su.Storage != nil {
+			if err := su.Storage.validate(su.PID); err != nil {
+				return err
+			}
+		}
+
+		if linked[su.PID] {
+			u, err := su.unit(s.TimeStep)
+			if err != nil {
+				return err
+			}
+			if len(u.RealPositivePowerLoc()) != 1 || len(u.RealNegativePowerLoc()) != 1 {
+				err := fmt.Sprintf("linked unit %v does not have a positive and negative power column", su.PID)
+				return errors.New(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// unit returns the unit described by su, with its capacity constraints applied, for time
+// steps of length t_step.
+func (su SiteUnit) unit(t_step float64) (Unit, error) {
+	if su.DemandCharge != nil && su.Type != "grid" {
+		err := fmt.Sprintf("unit %v has a demand charge but is not a grid unit", su.PID)
+		return nil, errors.New(err)
+	}
+	if (su.Cu != 0 || len(su.Availability) > 0) && su.Type != "renewable" {
+		err := fmt.Sprintf("unit %v has curtailment or availability but is not a renewable unit", su.PID)
+		return nil, errors.New(err)
+	}
+	if (su.Cs != 0 || len(su.Demand) > 0 || su.Deferrable != nil) && su.Type != "load" {
+		err := fmt.Sprintf("unit %v has shedding, demand or deferrable energy but is not a load unit", su.PID)
+		return nil, errors.New(err)
+	}
+	if su.Degradation != nil && su.Type != "storage" {
+		err := fmt.Sprintf("unit %v has degradation but is not a storage unit", su.PID)
+		return nil, errors.New(err)
+	}
+	if su.Emissions != nil && su.Emissions.StartUp != 0 && su.Type != "generator" {
+		err := fmt.Sprintf("unit %v has start up emissions but is not a generator unit", su.PID)
+		return nil, errors.New(err)
+	}
+
+	switch su.Type {
+	case "", "basic":
+		if len(su.Points) > 0 || su.Commitment != nil {
+			err := fmt.Sprintf("basic unit %v has critical points or commitment", su.PID)
+			return nil, errors.New(err)
+		}
+		u := NewBasicUnit(su.PID, su.Cp, su.Cn, su.Cc, su.Ce, upperBound(su.XpUb), upperBound(su.XnUb), upperBound(su.XcUb), upperBound(su.XeUb))
+		if su.CapacityConstraints {
+			if err := u.NewSparseConstraint(BasicUnitCapacityConstraints(&u)...); err != nil {
+				return nil, err
+			}
+		}
+		if su.Direction {
+			if err := u.EnableDirection(); err != nil {
+				err := fmt.Sprintf("unit %v: %v", su.PID, err)
+				return nil, errors.New(err)
+			}
+		}
+		return u, nil
+
+	case "piecewise":
+		if len(su.Points) < 2 {
+			err := fmt.Sprintf("piecewise unit %v has %v critical points, expected at least 2", su.PID, len(su.Points))
+			return nil, errors.New(err)
+		}
+		if su.Storage != nil || su.Direction || su.Commitment != nil {
+			err := fmt.Sprintf("piecewise unit %v cannot store energy, enable direction or commit", su.PID)
+			return nil, errors.New(err)
+		}
+		cx := make([]CriticalPoint, 0)
+		for _, p := range su.Points {
+			cx = append(cx, CriticalPoint{p.Value, p.Cost})
+		}
+		u := NewPiecewiseUnit(su.PID, cx)
+		if su.CapacityConstraints {
+			if err := u.NewSparseConstraint(PiecewiseUnitCapacityConstraints(&u)...); err != nil {
+				return nil, err
+			}
+		}
+		return u, nil
+
+	case "generator":
+		if su.Storage != nil || su.Direction || len(su.Points) > 0 {
+			err := fmt.Sprintf("generator unit %v cannot store energy, enable direction or have critical points", su.PID)
+			return nil, errors.New(err)
+		}
+		if su.XpUb == nil {
+			err := fmt.Sprintf("generator unit %v requires xp_ub", su.PID)
+			return nil, errors.New(err)
+		}
+		sc := SiteCommitment{}
+		if su.Commitment != nil {
+			sc = *su.Commitment
+		}
+		if sc.InitialSteps != nil && (sc.InitialOnline == nil || *sc.InitialSteps < 0) {
+			err := fmt.Sprintf("generator unit %v initial_steps requires initial_online and a non-negative value", su.PID)
+			return nil, errors.New(err)
+		}
+		u := NewGeneratorUnit(su.PID, su.Cp, su.Cc, sc.NoLoadCost, sc.StartUpCost, sc.ShutDownCost, sc.MinOutput, *su.XpUb)
+		if su.CapacityConstraints {
+			if err := u.NewSparseConstraint(GeneratorUnitCapacityConstraints(&u)...); err != nil {
+				return nil, err
+			}
+		}
+		return u, nil
+
+	case "grid":
+		if su.Storage != nil || su.CapacityConstraints || len(su.Points) > 0 || su.Commitment != nil {
+			err := fmt.Sprintf("grid unit %v takes only prices, limits, direction, ramp and demand charge", su.PID)
+			return nil, errors.New(err)
+		}
+		u := NewGridUnit(su.PID, su.Cp, -su.Cn, upperBound(su.XpUb), upperBound(su.XnUb))
+		if su.Direction {
+			if err := u.EnableDirection(); err != nil {
+				err := fmt.Sprintf("unit %v: %v", su.PID, err)
+				return nil, errors.New(err)
+			}
+		}
+		return u, nil
+
+	case "renewable":
+		if su.Storage != nil || su.Direction || su.CapacityConstraints || len(su.Points) > 0 || su.Commitment != nil {
+			err := fmt.Sprintf("renewable unit %v takes only costs, nameplate rating, ramp and availability", su.PID)
+			return nil, errors.New(err)
+		}
+		if su.XpUb == nil {
+			err := fmt.Sprintf("renewable unit %v requires xp_ub", su.PID)
+			return nil, errors.New(err)
+		}
+		return NewRenewableUnit(su.PID, su.Cp, su.Cu, *su.XpUb), nil
+
+	case "load":
+		if su.Storage != nil || su.Direction || su.CapacityConstraints || len(su.Points) > 0 || su.Commitment != nil {
+			err := fmt.Sprintf("load unit %v takes only costs, demand limit, ramp, demand and deferrable energy", su.PID)
+			return nil, errors.New(err)
+		}
+		if su.XnUb == nil {
+			err := fmt.Sprintf("load unit %v requires xn_ub", su.PID)
+			return nil, errors.New(err)
+		}
+		if su.Deferrable != nil {
+			if len(su.Demand) > 0 {
+				err := fmt.Sprintf("load unit %v has both demand and deferrable energy", su.PID)
+				return nil, errors.New(err)
+			}
+			return NewLoadUnit(su.PID, su.Cs, 0, *su.XnUb), nil
+		}
+		return NewLoadUnit(su.PID, su.Cs, *su.XnUb, *su.XnUb), nil
+
+	case "storage":
+		if su.Direction || su.Ce != 0 || len(su.Points) > 0 || su.Commitment != nil {
+			err := fmt.Sprintf("storage unit %v cannot enable direction, cost stored energy, have critical points or commit", su.PID)
+			return nil, errors.New(err)
+		}
+		sd := SiteDegradation{}
+		if su.Degradation != nil {
+			sd = *su.Degradation
+		}
+		u := NewStorageUnit(su.PID, su.Cp, su.Cn, su.Cc, sd.Throughput, upperBound(su.XpUb), upperBound(su.XnUb), upperBound(su.XcUb), upperBound(su.XeUb))
+		if len(sd.Depth) > 0 {
+			if su.Storage == nil {
+				err := fmt.Sprintf("storage unit %v has a depth cost but no storage", su.PID)
+				return nil, errors.New(err)
+			}
+			cx := make([]CriticalPoint, 0)
+			for _, p := range sd.Depth {
+				cx = append(cx, CriticalPoint{p.Value, p.Cost})
+			}
+			if err := u.EnableDepthCost(cx, t_step); err != nil {
+				err := fmt.Sprintf("unit %v: %v", su.PID, err)
+				return nil, errors.New(err)
+			}
+		}
+		if su.CapacityConstraints {
+			if err := u.NewSparseConstraint(StorageUnitCapacityConstraints(&u)...); err != nil {
+				return nil, err
+			}
+		}
+		return u, nil
+
+	default:
+		err := fmt.Sprintf("unit %v has unknown type: %v", su.PID, su.Type)
+		return nil, errors.New(err)
+	}
+}
+
+// efficiency returns the efficiency t_eta, or 1 if omitted.
+func efficiency(t_eta *float64) float64 {
+	if t_eta == nil {
+		return 1
+	}
+	return *t_eta
+}
+
+// upperBound returns the bound t_ub, or +Inf if omitted.
+func upperBound(t_ub *float64) float64 {
+	if t_ub == nil {
+		return math.Inf(1)
+	}
+	return *t_ub
+}
@@ -0,0 +1,544 @@
+package cgc_optimize
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSiteYAML = `
+horizon: 3
+time_step: 0.5
+units:
+  - pid: 11111111-1111-1111-1111-111111111111 # grid
+    cp: 10
+    cn: 1
+    xp_ub: 100
+    xn_ub: 100
+  - pid: 22222222-2222-2222-2222-222222222222 # battery
+    cp: 0.1
+    cn: 0.1
+    xp_ub: 20
+    xn_ub: 20
+    xc_ub: 20
+    xe_ub: 40
+    capacity_constraints: true
+    storage:
+      initial_energy: 20
+      charge_efficiency: 0.95
+      discharge_efficiency: 0.95
+      cyclic: true
+      reserve: 4
+  - pid: 33333333-3333-3333-3333-333333333333 # feeder tie
+    xp_ub: 50
+    xn_ub: 50
+  - pid: 44444444-4444-4444-4444-444444444444 # genset
+    type: piecewise
+    points:
+      - {value: 0, cost: 0}
+      - {value: 10, cost: 5}
+      - {value: 20, cost: 8}
+groups:
+  - units:
+      - 11111111-1111-1111-1111-111111111111
+      - 33333333-3333-3333-3333-333333333333
+    net_load: [0, 0, 0]
+  - units:
+      - 22222222-2222-2222-2222-222222222222
+      - 33333333-3333-3333-3333-333333333333
+      - 44444444-4444-4444-4444-444444444444
+    net_load: [10, 30, 5]
+    capacity: [0, 10, 0]
+links:
+  - 33333333-3333-3333-3333-333333333333
+`
+
+func TestParseSiteYAML(t *testing.T) {
+	site, err := ParseSiteYAML([]byte(testSiteYAML))
+	assert.Nil(t, err)
+
+	assert.Equal(t, 3, site.Horizon)
+	assert.Equal(t, 0.5, site.TimeStep)
+	assert.Len(t, site.Units, 4)
+	assert.Equal(t, uuid.MustParse("22222222-2222-2222-2222-222222222222"), site.Units[1].PID)
+	assert.Equal(t, 40.0, *site.Units[1].XeUb)
+	assert.Equal(t, 20.0, *site.Units[1].XcUb)
+	assert.Nil(t, site.Units[0].XcUb)
+	assert.Equal(t, 20.0, *site.Units[1].Storage.InitialEnergy)
+	assert.Equal(t, 0.95, *site.Units[1].Storage.ChargeEfficiency)
+	assert.Equal(t, []SitePoint{{0, 0}, {10, 5}, {20, 8}}, site.Units[3].Points)
+	assert.Equal(t, []float64{10, 30, 5}, site.Groups[1].NetLoad)
+}
+
+func TestNewSiteSeries(t *testing.T) {
+	site, err := ParseSiteYAML([]byte(testSiteYAML))
+	assert.Nil(t, err)
+
+	se, err := NewSiteSeries(site)
+	assert.Nil(t, err)
+
+	// grid 4 + tie 4 + battery 4 + tie 4 + genset 5 columns per step
+	assert.Equal(t, 3*21, se.ColumnSize())
+
+	names := se.ConstraintNames()
+	assert.Contains(t, names, "t1.g1.net_load")
+	assert.Contains(t, names, "t1.g1.positive_capacity")
+	assert.Contains(t, names, "t2.33333333-3333-3333-3333-333333333333.linked_bus_positive")
+	assert.Contains(t, names, "t0.g1.22222222-2222-2222-2222-222222222222.positive_capacity")
+	assert.Contains(t, names, "22222222-2222-2222-2222-222222222222.energy_balance.t1")
+	assert.Contains(t, names, "22222222-2222-2222-2222-222222222222.initial_energy")
+	assert.Contains(t, names, "22222222-2222-2222-2222-222222222222.cyclic_energy")
+	assert.Contains(t, names, "22222222-2222-2222-2222-222222222222.energy_reserve.t2")
+
+	res, err := NativeSolver{}.SolveLp(&se, SolverOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, StatusOptimal, res.Status)
+}
+
+func TestParseSiteJSON(t *testing.T) {
+	js := `{
+		"horizon": 2,
+		"time_step": 1,
+		"units": [{"pid": "11111111-1111-1111-1111-111111111111", "cp": 1, "xp_ub": 10}],
+		"groups": [{"units": ["11111111-1111-1111-1111-111111111111"], "net_load": [4, 5]}]
+	}`
+	site, err := ParseSiteJSON([]byte(js))
+	assert.Nil(t, err)
+
+	se, err := NewSiteSeries(site)
+	assert.Nil(t, err)
+	assert.Equal(t, 8, se.ColumnSize())
+
+	res, err := NativeSolver{}.SolveLp(&se, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 9.0, res.Objective, 1e-9)
+
+	_, err = ParseSiteJSON([]byte(`{"horizon": 2, "timestep": 1}`))
+	assert.Error(t, err, "unknown field accepted")
+}
+
+func TestSiteValidation(t *testing.T) {
+	pid1 := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	pid2 := uuid.MustParse("22222222-2222-2222-2222-222222222222")
+	valid := func() Site {
+		return Site{
+			Horizon:  2,
+			TimeStep: 1,
+			Units:    []SiteUnit{{PID: pid1}, {PID: pid2}},
+			Groups:   []SiteGroup{{Units: []uuid.UUID{pid1, pid2}}},
+		}
+	}
+
+	_, err := NewSiteSeries(valid())
+	assert.Nil(t, err)
+
+	site := valid()
+	site.Horizon = 0
+	_, err = NewSiteSeries(site)
+	assert.Error(t, err, "empty horizon accepted")
+
+	site = valid()
+	site.Groups[0].NetLoad = []float64{1}
+	_, err = NewSiteSeries(site)
+	assert.Error(t, err, "short forecast accepted")
+
+	site = valid()
+	site.Groups = []SiteGroup{{Units: []uuid.UUID{pid1}}}
+	_, err = NewSiteSeries(site)
+	assert.Error(t, err, "unassigned unit accepted")
+
+	site = valid()
+	site.Groups = append(site.Groups, SiteGroup{Units: []uuid.UUID{pid2}})
+	_, err = NewSiteSeries(site)
+	assert.Error(t, err, "unlinked shared unit accepted")
+
+	site.Links = []uuid.UUID{pid2}
+	_, err = NewSiteSeries(site)
+	assert.Nil(t, err)
+
+	site.Units[1].Type = "renewable"
+	site.Units[1].Availability = []float64{1, 2}
+	_, err = NewSiteSeries(site)
+	assert.Error(t, err, "linked unit without a negative power column accepted")
+
+	for _, eta := range []float64{1.1, 0, -0.5} {
+		eta := eta
+		site = valid()
+		site.Units[1].Storage = &SiteStorage{ChargeEfficiency: &eta}
+		_, err = NewSiteSeries(site)
+		assert.Error(t, err, "efficiency outside of (0, 1] accepted")
+	}
+
+	site = valid()
+	site.Units[1].Storage = &SiteStorage{}
+	_, err = NewSiteSeries(site)
+	assert.Nil(t, err, "omitted efficiency rejected")
+
+	site = valid()
+	site.Units[1].Direction = true
+	_, err = NewSiteSeries(site)
+	assert.Error(t, err, "direction without power bounds accepted")
+
+	site = valid()
+	site.Units[1].Type = "piecewise"
+	_, err = NewSiteSeries(site)
+	assert.Error(t, err, "piecewise unit without critical points accepted")
+}
+
+func TestLoadSite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "site")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "site.yaml")
+	err = ioutil.WriteFile(path, []byte(testSiteYAML), 0644)
+	assert.Nil(t, err)
+
+	se, err := LoadSite(path)
+	assert.Nil(t, err)
+	assert.Equal(t, 3*21, se.ColumnSize())
+
+	_, err = LoadSite(filepath.Join(dir, "site.toml"))
+	assert.Error(t, err)
+}
+
+func TestSiteGenerator(t *testing.T) {
+	y := `
+horizon: 3
+time_step: 1
+units:
+  - pid: 55555555-5555-5555-5555-555555555555
+    type: generator
+    cp: 1
+    xp_ub: 10
+    commitment:
+      min_output: 5
+      start_up_cost: 20
+      min_up: 2
+      initial_online: false
+groups:
+  - units: [55555555-5555-5555-5555-555555555555]
+    net_load: [0, 6, 6]
+`
+	site, err := ParseSiteYAML([]byte(y))
+	assert.Nil(t, err)
+	se, err := NewSiteSeries(site)
+	assert.Nil(t, err)
+
+	p := "55555555-5555-5555-5555-555555555555"
+	names := se.ConstraintNames()
+	assert.Contains(t, names, p+".transition.t2")
+	assert.Contains(t, names, p+".initial_status")
+	assert.Contains(t, names, p+".min_up.t1")
+	assert.Equal(t, 3, sumInts(se.Integrality()))
+
+	res, err := NativeSolver{}.SolveMip(se, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 32, res.Objective, 1e-6)
+
+	// started one step before the series, the generator stays online for the first step
+	online, steps := true, 1
+	site.Units[0].Commitment.InitialOnline = &online
+	site.Units[0].Commitment.InitialSteps = &steps
+	se, err = NewSiteSeries(site)
+	assert.Nil(t, err)
+	assert.Contains(t, se.ConstraintNames(), p+".initial_min_up.t0")
+	assert.NotContains(t, se.ConstraintNames(), p+".initial_min_up.t1")
+
+	site.Units[0].Commitment.InitialOnline = nil
+	_, err = NewSiteSeries(site)
+	assert.Error(t, err, "initial steps without initial status accepted")
+	site.Units[0].Commitment.InitialSteps = nil
+
+	site.Units[0].XpUb = nil
+	_, err = NewSiteSeries(site)
+	assert.Error(t, err, "generator without xp_ub accepted")
+}
+
+func TestSiteRamp(t *testing.T) {
+	y := `
+horizon: 2
+time_step: 0.5
+units:
+  - pid: 66666666-6666-6666-6666-666666666666
+    cp: 1
+    xp_ub: 20
+    ramp: {up: 10, initial_output: 0}
+  - pid: 77777777-7777-7777-7777-777777777777
+    cp: 10
+groups:
+  - units: [66666666-6666-6666-6666-666666666666, 77777777-7777-7777-7777-777777777777]
+    net_load: [8, 8]
+`
+	site, err := ParseSiteYAML([]byte(y))
+	assert.Nil(t, err)
+	se, err := NewSiteSeries(site)
+	assert.Nil(t, err)
+
+	p := "66666666-6666-6666-6666-666666666666"
+	names := se.ConstraintNames()
+	assert.Contains(t, names, p+".ramp.t1")
+	assert.Contains(t, names, p+".initial_ramp")
+
+	res, err := NativeSolver{}.SolveLp(se, SolverOptions{})
+	assert.Nil(t, err)
+	srx, err := se.Decode(res.Columns)
+	assert.Nil(t, err)
+	ux := UnitSeries(srx, uuid.MustParse(p))
+	assert.InDelta(t, 5, ux[0].RealPositivePower, 1e-6)
+	assert.InDelta(t, 8, ux[1].RealPositivePower, 1e-6)
+}
+
+func TestSiteGrid(t *testing.T) {
+	y := `
+horizon: 3
+time_step: 1
+units:
+  - pid: 88888888-8888-8888-8888-888888888888
+    type: grid
+    cp: 1
+    cn: -0.5
+    xn_ub: 5
+    demand_charge: {price: 2, peak: 3}
+  - pid: 99999999-9999-9999-9999-999999999999
+    cp: 0.1
+    xp_ub: 8
+groups:
+  - units: [88888888-8888-8888-8888-888888888888, 99999999-9999-9999-9999-999999999999]
+    net_load: [10, 2, 4]
+`
+	site, err := ParseSiteYAML([]byte(y))
+	assert.Nil(t, err)
+	se, err := NewSiteSeries(site)
+	assert.Nil(t, err)
+
+	p := "88888888-8888-8888-8888-888888888888"
+	assert.Contains(t, se.ConstraintNames(), p+".demand.t0")
+	names := se.ColumnNames()
+	assert.Equal(t, p+".peak", names[len(names)-1])
+
+	// the grid imports the load over the generator limit at t0, and the generator exports up
+	// to the export limit after
+	res, err := NativeSolver{}.SolveLp(se, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 3, res.Columns[len(names)-1], 1e-6)
+	assert.InDelta(t, 0.1*(8+7+8)+2-0.5*(5+4)+2*3, res.Objective, 1e-6)
+
+	site.Units[1].DemandCharge = &SiteDemandCharge{Price: 1}
+	_, err = NewSiteSeries(site)
+	assert.Error(t, err, "demand charge on basic unit accepted")
+}
+
+func TestSiteRenewable(t *testing.T) {
+	y := `
+horizon: 2
+time_step: 1
+units:
+  - pid: aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa
+    type: renewable
+    cu: 0.1
+    xp_ub: 10
+    availability: [3, 8]
+  - pid: bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb
+    cp: 1
+    xn_ub: 0
+groups:
+  - units: [aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa, bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb]
+    net_load: [5, 5]
+`
+	site, err := ParseSiteYAML([]byte(y))
+	assert.Nil(t, err)
+	se, err := NewSiteSeries(site)
+	assert.Nil(t, err)
+
+	res, err := NativeSolver{}.SolveLp(se, SolverOptions{})
+	assert.Nil(t, err)
+	srx, err := se.Decode(res.Columns)
+	assert.Nil(t, err)
+	ux := UnitSeries(srx, uuid.MustParse("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa"))
+	assert.InDelta(t, 3, ux[0].RealPositivePower, 1e-6)
+	assert.InDelta(t, 3, ux[1].CurtailedPower, 1e-6)
+
+	site.Units[0].Availability = []float64{3}
+	_, err = NewSiteSeries(site)
+	assert.Error(t, err, "short availability forecast accepted")
+
+	site.Units[0].Availability = nil
+	site.Units[1].Cu = 1
+	_, err = NewSiteSeries(site)
+	assert.Error(t, err, "curtailment cost on basic unit accepted")
+}
+
+func TestSiteLoad(t *testing.T) {
+	y := `
+horizon: 3
+time_step: 1
+units:
+  - pid: cccccccc-cccc-cccc-cccc-cccccccccccc
+    type: load
+    cs: 100
+    xn_ub: 10
+    demand: [4, 12, 4]
+  - pid: dddddddd-dddd-dddd-dddd-dddddddddddd
+    type: load
+    cs: 50
+    xn_ub: 3
+    deferrable: {energy: 3, first: 1, last: 2}
+  - pid: eeeeeeee-eeee-eeee-eeee-eeeeeeeeeeee
+    cp: 1
+    xp_ub: 10
+    xn_ub: 0
+groups:
+  - units: [cccccccc-cccc-cccc-cccc-cccccccccccc, dddddddd-dddd-dddd-dddd-dddddddddddd, eeeeeeee-eeee-eeee-eeee-eeeeeeeeeeee]
+    net_load: [0, 0, 0]
+`
+	site, err := ParseSiteYAML([]byte(y))
+	assert.Nil(t, err)
+	se, err := NewSiteSeries(site)
+	assert.Nil(t, err)
+
+	res, err := NativeSolver{}.SolveLp(se, SolverOptions{})
+	assert.Nil(t, err)
+	srx, err := se.Decode(res.Columns)
+	assert.Nil(t, err)
+
+	// at t1 the 12 kW demand is limited to 10 kW and the deferrable load waits for t2
+	fixed := UnitSeries(srx, uuid.MustParse("cccccccc-cccc-cccc-cccc-cccccccccccc"))
+	deferred := UnitSeries(srx, uuid.MustParse("dddddddd-dddd-dddd-dddd-dddddddddddd"))
+	assert.InDelta(t, 2, fixed[1].Shed, 1e-6)
+	assert.InDelta(t, 0, deferred[1].RealNegativePower, 1e-6)
+	assert.InDelta(t, 3, deferred[2].RealNegativePower, 1e-6)
+	assert.InDelta(t, 4+10+7+100*2, res.Objective, 1e-6)
+
+	site.Units[1].Deferrable.Last = 3
+	_, err = NewSiteSeries(site)
+	assert.Error(t, err, "deferrable window past horizon accepted")
+}
+
+func TestSiteEmissions(t *testing.T) {
+	y := `
+horizon: 2
+time_step: 0.5
+emissions_cap: 4
+units:
+  - pid: aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa
+    cp: 1
+    xp_ub: 10
+    xn_ub: 0
+    emissions: {energy: 2}
+  - pid: bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb
+    cp: 3
+    xp_ub: 10
+    xn_ub: 0
+groups:
+  - units: [aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa, bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb]
+    net_load: [4, 4]
+`
+	site, err := ParseSiteYAML([]byte(y))
+	assert.Nil(t, err)
+	se, err := NewSiteSeries(site)
+	assert.Nil(t, err)
+	assert.Contains(t, se.ConstraintNames(), "emissions_cap")
+
+	res, err := NativeSolver{}.SolveLp(se, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 1*4+3*4, res.Objective, 1e-6)
+
+	srx, err := se.Decode(res.Columns)
+	assert.Nil(t, err)
+	dirty := UnitSeries(srx, uuid.MustParse("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa"))
+	assert.InDelta(t, 4, dirty[0].Emissions+dirty[1].Emissions, 1e-6)
+
+	site.Units[0].Emissions.StartUp = 1
+	_, err = NewSiteSeries(site)
+	assert.Error(t, err, "start up emissions of basic unit accepted")
+}
+
+func TestSiteStorageDegradation(t *testing.T) {
+	y := `
+horizon: 2
+time_step: 1
+units:
+  - pid: aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa
+    type: storage
+    cp: 0.1
+    xp_ub: 5
+    xn_ub: 5
+    xc_ub: 5
+    xe_ub: 10
+    capacity_constraints: true
+    storage: {initial_energy: 10}
+    degradation:
+      throughput: 0.02
+      depth: [{value: 0, cost: 0}, {value: 5, cost: 0}, {value: 10, cost: 1}]
+groups:
+  - units: [aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa]
+`
+	site, err := ParseSiteYAML([]byte(y))
+	assert.Nil(t, err)
+	se, err := NewSiteSeries(site)
+	assert.Nil(t, err)
+
+	pid := uuid.MustParse("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa")
+	assert.Equal(t, 20, se.ColumnSize())
+	assert.InDelta(t, 0.12, se.CostCoefficients()[se.RealPositivePowerPidLoc(pid)[0]], 1e-9)
+	assert.InDelta(t, 0.02, se.CostCoefficients()[se.RealNegativePowerPidLoc(pid)[0]], 1e-9)
+	assert.Contains(t, se.ConstraintNames(), "t0.g0."+pid.String()+".depth_energy")
+	assert.Contains(t, se.ConstraintNames(), pid.String()+".depth_balance1.t0")
+	assert.Contains(t, se.ConstraintNames(), pid.String()+".depth_final1")
+	assert.Contains(t, se.ColumnNames(), "t1.g0."+pid.String()+".xe1")
+
+	site.Units[0].Degradation.Depth[2].Value = 8
+	_, err = NewSiteSeries(site)
+	assert.Error(t, err, "depth cost short of stored energy bound accepted")
+
+	site.Units[0].Degradation.Depth[2].Value = 10
+	site.Units[0].Storage = nil
+	_, err = NewSiteSeries(site)
+	assert.Error(t, err, "depth cost without storage accepted")
+
+	site.Units[0].Degradation.Depth = nil
+	site.Units[0].Type = "basic"
+	_, err = NewSiteSeries(site)
+	assert.Error(t, err, "degradation of basic unit accepted")
+}
+
+func TestSiteLoadRamp(t *testing.T) {
+	y := `
+horizon: 2
+time_step: 1
+units:
+  - pid: cccccccc-cccc-cccc-cccc-cccccccccccc
+    type: load
+    cs: 100
+    xn_ub: 10
+    demand: [2, 10]
+    ramp: {up: 3, down: 3, initial_output: 0}
+  - pid: eeeeeeee-eeee-eeee-eeee-eeeeeeeeeeee
+    cp: 1
+    xp_ub: 10
+    xn_ub: 0
+groups:
+  - units: [cccccccc-cccc-cccc-cccc-cccccccccccc, eeeeeeee-eeee-eeee-eeee-eeeeeeeeeeee]
+    net_load: [0, 0]
+`
+	site, err := ParseSiteYAML([]byte(y))
+	assert.Nil(t, err)
+	se, err := NewSiteSeries(site)
+	assert.Nil(t, err)
+
+	res, err := NativeSolver{}.SolveLp(se, SolverOptions{})
+	assert.Nil(t, err)
+	srx, err := se.Decode(res.Columns)
+	assert.Nil(t, err)
+
+	// served load rises by at most 3 kW per hour, the rest of the demand is shed
+	load := UnitSeries(srx, uuid.MustParse("cccccccc-cccc-cccc-cccc-cccccccccccc"))
+	assert.InDelta(t, 2, load[0].RealNegativePower, 1e-6)
+	assert.InDelta(t, 5, load[1].RealNegativePower, 1e-6)
+	assert.InDelta(t, 5, load[1].Shed, 1e-6)
+}
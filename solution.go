@@ -0,0 +1,167 @@
+package cgc_optimize
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// UnitResult is the dispatch of a single unit decoded from a solution vector.
+//
+// Online: Commitment status of units with commitment decisions, 0 otherwise
+// CurtailedPower: Available power not dispatched by curtailable units, 0 otherwise
+// Shed: Demand not served by sheddable load units, 0 otherwise
+// Emissions: Emissions of units with an emission factor set on the series, 0 otherwise
+type UnitResult struct {
+	PID               uuid.UUID
+	RealPositivePower float64
+	RealNegativePower float64
+	RealCapacity      float64
+	StoredEnergy      float64
+	Online            float64
+	CurtailedPower    float64
+	Shed              float64
+	Emissions         float64
+}
+
+// CurtailedEnergy returns the energy curtailed by the unit over a time step of t_tstep
+// hours.
+func (r UnitResult) CurtailedEnergy(t_tstep float64) float64 {
+	return r.CurtailedPower * t_tstep
+}
+
+// StepResult maps unit PIDs to their dispatch within a single time step.
+type StepResult map[uuid.UUID]UnitResult
+
+// Decoder maps a solution vector back to the dispatch of the units it contains.
+type Decoder interface {
+	Decode([]float64) (StepResult, error)
+}
+
+// DecodeUnit returns the dispatch of unit u from t_x, the slice of the solution vector
+// holding the unit's columns. Where a unit exposes several columns of the same kind
+// their values are summed.
+func DecodeUnit(u Unit, t_x []float64) (UnitResult, error) {
+	if len(t_x) != u.ColumnSize() {
+		err := fmt.Sprintf("solution contains %v columns, expected: %v", len(t_x), u.ColumnSize())
+		return UnitResult{}, errors.New(err)
+	}
+
+	r := UnitResult{PID: u.PID()}
+	r.RealPositivePower = sumLoc(t_x, u.RealPositivePowerLoc())
+	r.RealNegativePower = sumLoc(t_x, u.RealNegativePowerLoc())
+	r.RealCapacity = sumLoc(t_x, u.RealCapacityLoc())
+	r.StoredEnergy = sumLoc(t_x, u.StoredEnergyLoc())
+	r.Online = sumLoc(t_x, onlineLoc(u))
+	r.CurtailedPower = sumLoc(t_x, curtailedLoc(u))
+	r.Shed = sumLoc(t_x, shedLoc(u))
+
+	return r, nil
+}
+
+// Decode returns the dispatch of every unit in the group keyed by PID.
+func (g Group) Decode(t_x []float64) (StepResult, error) {
+	if len(t_x) != g.ColumnSize() {
+		err := fmt.Sprintf("solution contains %v columns, expected: %v", len(t_x), g.ColumnSize())
+		return StepResult{}, errors.New(err)
+	}
+
+	sr := make(StepResult)
+	i := 0
+	for _, u := range g.units {
+		if _, ok := sr[u.PID()]; !ok {
+			r, err := DecodeUnit(u, t_x[i:i+u.ColumnSize()])
+			if err != nil {
+				return StepResult{}, err
+			}
+			sr[u.PID()] = r
+		}
+		i += u.ColumnSize()
+	}
+
+	return sr, nil
+}
+
+// Decode returns the dispatch of every unit in the cluster keyed by PID. A unit linked
+// across several groups is reported once, using its columns in the first group.
+func (cl Cluster) Decode(t_x []float64) (StepResult, error) {
+	if len(t_x) != cl.ColumnSize() {
+		err := fmt.Sprintf("solution contains %v columns, expected: %v", len(t_x), cl.ColumnSize())
+		return StepResult{}, errors.New(err)
+	}
+
+	sr := make(StepResult)
+	i := 0
+	for _, g := range cl.groups {
+		gr, err := g.Decode(t_x[i : i+g.ColumnSize()])
+		if err != nil {
+			return StepResult{}, err
+		}
+		mergeStepResult(sr, gr)
+		i += g.ColumnSize()
+	}
+
+	return sr, nil
+}
+
+// Decode returns the dispatch of every unit in the series, one StepResult per time step,
+// with the emissions of each unit with an emission factor.
+func (se Series) Decode(t_x []float64) ([]StepResult, error) {
+	if len(t_x) != se.ColumnSize() {
+		err := fmt.Sprintf("solution contains %v columns, expected: %v", len(t_x), se.ColumnSize())
+		return []StepResult{}, errors.New(err)
+	}
+
+	srx := make([]StepResult, 0)
+	i := 0
+	for _, cl := range se.clusters {
+		sr, err := cl.Decode(t_x[i : i+cl.ColumnSize()])
+		if err != nil {
+			return []StepResult{}, err
+		}
+		for _, pid := range se.emitters() {
+			if r, ok := sr[pid]; ok {
+				r.Emissions = se.stepEmissions(len(srx), pid).Evaluate(t_x)
+				sr[pid] = r
+			}
+		}
+		srx = append(srx, sr)
+		i += cl.ColumnSize()
+	}
+
+	return srx, nil
+}
+
+// UnitSeries returns the dispatch of unit t_pid at every time step in t_srx. Steps in
+// which the unit does not appear are reported as a zero UnitResult.
+func UnitSeries(t_srx []StepResult, t_pid uuid.UUID) []UnitResult {
+	rx := make([]UnitResult, len(t_srx))
+	for i, sr := range t_srx {
+		r, ok := sr[t_pid]
+		if !ok {
+			r = UnitResult{PID: t_pid}
+		}
+		rx[i] = r
+	}
+
+	return rx
+}
+
+// mergeStepResult adds the units in src to dst, keeping any entry already in dst.
+func mergeStepResult(dst StepResult, src StepResult) {
+	for pid, r := range src {
+		if _, ok := dst[pid]; !ok {
+			dst[pid] = r
+		}
+	}
+}
+
+// sumLoc returns the sum of the values of t_x located at t_loc
+func sumLoc(t_x []float64, t_loc []int) float64 {
+	var s float64
+	for _, i := range t_loc {
+		s += t_x[i]
+	}
+	return s
+}
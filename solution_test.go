@@ -0,0 +1,74 @@
+package cgc_optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeGroup(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	pid2, _ := uuid.NewUUID()
+	inf := math.Inf(1)
+	a1 := NewBasicUnit(pid1, 1, 2, 3, 4, inf, inf, inf, inf)
+	a2 := NewBasicUnit(pid2, 5, 6, 7, 8, inf, inf, inf, inf)
+	g := NewGroup(a1, a2)
+
+	sr, err := g.Decode([]float64{1, 2, 3, 4, 5, 6, 7, 8})
+	assert.Nil(t, err)
+	assert.Equal(t, UnitResult{PID: pid1, RealPositivePower: 1, RealNegativePower: 2, RealCapacity: 3, StoredEnergy: 4}, sr[pid1])
+	assert.Equal(t, UnitResult{PID: pid2, RealPositivePower: 5, RealNegativePower: 6, RealCapacity: 7, StoredEnergy: 8}, sr[pid2])
+	assert.Len(t, sr, 2)
+}
+
+func TestDecodeGroupBadSolution(t *testing.T) {
+	g := NewTestGroup()
+	_, err := g.Decode([]float64{1, 2, 3})
+	assert.Error(t, err)
+}
+
+func TestDecodeClusterLinkedUnit(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	pid2, _ := uuid.NewUUID()
+	inf := math.Inf(1)
+	a1 := NewBasicUnit(pid1, 1, 2, 3, 4, inf, inf, inf, inf)
+	a2 := NewBasicUnit(pid2, 5, 6, 7, 8, inf, inf, inf, inf)
+	cl := NewCluster(NewGroup(a2, a1), NewGroup(a1))
+
+	sr, err := cl.Decode([]float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12})
+	assert.Nil(t, err)
+	assert.Equal(t, UnitResult{PID: pid2, RealPositivePower: 1, RealNegativePower: 2, RealCapacity: 3, StoredEnergy: 4}, sr[pid2])
+	assert.Equal(t, UnitResult{PID: pid1, RealPositivePower: 5, RealNegativePower: 6, RealCapacity: 7, StoredEnergy: 8}, sr[pid1], "linked unit not decoded from first group")
+	assert.Len(t, sr, 2)
+}
+
+func TestDecodeSeries(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	inf := math.Inf(1)
+	a1 := NewBasicUnit(pid1, 1, 2, 3, 4, inf, inf, inf, inf)
+	g := NewGroup(a1)
+	s := NewSeries(g, g, g)
+
+	srx, err := s.Decode([]float64{10, 0, 10, 20, 10, 0, 10, 15, 0, 5, 5, 10})
+	assert.Nil(t, err)
+	assert.Len(t, srx, 3)
+
+	ux := UnitSeries(srx, pid1)
+	assert.Equal(t, []UnitResult{
+		{PID: pid1, RealPositivePower: 10, RealNegativePower: 0, RealCapacity: 10, StoredEnergy: 20},
+		{PID: pid1, RealPositivePower: 10, RealNegativePower: 0, RealCapacity: 10, StoredEnergy: 15},
+		{PID: pid1, RealPositivePower: 0, RealNegativePower: 5, RealCapacity: 5, StoredEnergy: 10},
+	}, ux)
+}
+
+func TestDecodePiecewiseUnit(t *testing.T) {
+	pid, _ := uuid.NewUUID()
+	cp := []CriticalPoint{{-5, 0.8}, {0, 0}, {5, 1.2}}
+	pu := NewPiecewiseUnit(pid, cp)
+
+	r, err := DecodeUnit(pu, []float64{1, 2, 3, 0, 1})
+	assert.Nil(t, err)
+	assert.Equal(t, UnitResult{PID: pid, RealPositivePower: 1, RealNegativePower: 2, RealCapacity: 3, StoredEnergy: 0}, r)
+}
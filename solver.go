@@ -0,0 +1,74 @@
+package cgc_optimize
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Solver solves linear and mixed integer linear programs. Implementations make
+// themselves available by name with RegisterSolver.
+type Solver interface {
+	SolveLp(LinearProgram, SolverOptions) (Result, error)
+	SolveMip(MipLinearProgram, SolverOptions) (Result, error)
+}
+
+// SolverOptions configures a single solve. Zero values select the solver defaults.
+//
+// TimeLimit: wall clock limit for the solve
+// MipGap: relative optimality gap at which a MIP solve terminates
+// WarmStart: initial guess of the column solution, e.g. a previous solution; solvers
+// may ignore it
+type SolverOptions struct {
+	TimeLimit time.Duration
+	MipGap    float64
+	WarmStart []float64
+}
+
+var (
+	solversMu sync.RWMutex
+	solvers   = make(map[string]Solver)
+)
+
+// RegisterSolver makes a solver available by name. It panics if s is nil or a solver is
+// already registered under the name.
+func RegisterSolver(t_name string, s Solver) {
+	solversMu.Lock()
+	defer solversMu.Unlock()
+
+	if s == nil {
+		panic("cgc_optimize: RegisterSolver solver is nil")
+	}
+	if _, dup := solvers[t_name]; dup {
+		panic("cgc_optimize: RegisterSolver called twice for solver " + t_name)
+	}
+	solvers[t_name] = s
+}
+
+// NewSolver returns the solver registered under the name.
+func NewSolver(t_name string) (Solver, error) {
+	solversMu.RLock()
+	defer solversMu.RUnlock()
+
+	s, ok := solvers[t_name]
+	if !ok {
+		err := fmt.Sprintf("unknown solver %q (forgotten import?)", t_name)
+		return nil, errors.New(err)
+	}
+	return s, nil
+}
+
+// Solvers returns the sorted names of the registered solvers.
+func Solvers() []string {
+	solversMu.RLock()
+	defer solversMu.RUnlock()
+
+	names := make([]string, 0, len(solvers))
+	for name := range solvers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
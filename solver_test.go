@@ -0,0 +1,39 @@
+package cgc_optimize
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testSolver struct{}
+
+func (testSolver) SolveLp(LinearProgram, SolverOptions) (Result, error) {
+	return Result{}, nil
+}
+
+func (testSolver) SolveMip(MipLinearProgram, SolverOptions) (Result, error) {
+	return Result{}, nil
+}
+
+func TestRegisterSolver(t *testing.T) {
+	RegisterSolver("test", testSolver{})
+	defer func() {
+		solversMu.Lock()
+		delete(solvers, "test")
+		solversMu.Unlock()
+	}()
+
+	s, err := NewSolver("test")
+	assert.Nil(t, err)
+	assert.Equal(t, testSolver{}, s)
+	assert.Contains(t, Solvers(), "test")
+
+	assert.Panics(t, func() { RegisterSolver("test", testSolver{}) })
+	assert.Panics(t, func() { RegisterSolver("nil", nil) })
+}
+
+func TestUnknownSolver(t *testing.T) {
+	_, err := NewSolver("unknown")
+	assert.Error(t, err)
+}
@@ -0,0 +1,210 @@
+// Package solvertest provides the conformance suite run against every opt.Solver
+// implementation.
+package solvertest
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/google/uuid"
+	opt "github.com/ohowland/cgc_optimize"
+	"github.com/stretchr/testify/assert"
+)
+
+// Run runs the conformance suite against s.
+func Run(t *testing.T, s opt.Solver) {
+	t.Run("NetLoadConstraint", func(t *testing.T) { testNetLoadConstraint(t, s) })
+	t.Run("AssetCapacityConstraint", func(t *testing.T) { testAssetCapacityConstraint(t, s) })
+	t.Run("GroupCapacityConstraint", func(t *testing.T) { testGroupCapacityConstraint(t, s) })
+	t.Run("ClusterLinkedBusConstraint", func(t *testing.T) { testClusterLinkedBusConstraint(t, s) })
+	t.Run("SeriesDischargeBatteryConstraint", func(t *testing.T) { testSeriesDischargeBatteryConstraint(t, s) })
+	t.Run("SeriesChargeBatteryConstraint", func(t *testing.T) { testSeriesChargeBatteryConstraint(t, s) })
+	t.Run("InfeasibleNetLoad", func(t *testing.T) { testInfeasibleNetLoad(t, s) })
+}
+
+// RunMip runs the mixed integer conformance suite against s. Solvers limited to linear
+// programs run only Run.
+func RunMip(t *testing.T, s opt.Solver) {
+	t.Run("SeriesDirectionMip", func(t *testing.T) { testSeriesDirectionMip(t, s) })
+	t.Run("PiecewiseUnitMip", func(t *testing.T) { testPiecewiseUnitMip(t, s) })
+}
+
+func testNetLoadConstraint(t *testing.T, s opt.Solver) {
+	pid1, _ := uuid.NewUUID()
+	pid2, _ := uuid.NewUUID()
+	a1 := opt.NewBasicUnit(pid1, 1.0, 2.0, 0.01, 0, 5, 5, 5, 5)
+	a2 := opt.NewBasicUnit(pid2, 5.0, 6.0, 0.01, 0, 10, 10, 10, 10)
+	ag1 := opt.NewGroup(a1, a2)
+
+	nlc := opt.NetLoadConstraint(&ag1, 10)
+	ag1.NewSparseConstraint(nlc)
+
+	res, err := s.SolveLp(ag1, opt.SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDeltaSlice(t, []float64{5, 0, 0, 0, 5, 0, 0, 0}, res.Columns, 0.1)
+}
+
+func testAssetCapacityConstraint(t *testing.T, s opt.Solver) {
+	pid1, _ := uuid.NewUUID()
+	pid2, _ := uuid.NewUUID()
+	a1 := opt.NewBasicUnit(pid1, 1.0, 2.0, 0.01, 0, 5, 5, 5, 5)
+	a1.NewSparseConstraint(opt.BasicUnitCapacityConstraints(&a1)...)
+	a2 := opt.NewBasicUnit(pid2, 5.0, 6.0, 0.01, 0, 10, 10, 10, 10)
+	a2.NewSparseConstraint(opt.BasicUnitCapacityConstraints(&a2)...)
+
+	ag1 := opt.NewGroup(a1, a2)
+
+	nlc := opt.NetLoadConstraint(&ag1, 10)
+	ag1.NewSparseConstraint(nlc)
+
+	res, err := s.SolveLp(ag1, opt.SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDeltaSlice(t, []float64{5, 0, 5, 0, 5, 0, 5, 0}, res.Columns, 0.1)
+}
+
+func testGroupCapacityConstraint(t *testing.T, s opt.Solver) {
+	pid1, _ := uuid.NewUUID()
+	pid2, _ := uuid.NewUUID()
+	a1 := opt.NewBasicUnit(pid1, 1.0, 2.0, 0.01, 0, 5, 5, 5, 5)
+	err := a1.NewSparseConstraint(opt.BasicUnitCapacityConstraints(&a1)...)
+	assert.Nil(t, err)
+	a2 := opt.NewBasicUnit(pid2, 5.0, 6.0, 0.01, 0, 10, 10, 10, 10)
+	err = a2.NewSparseConstraint(opt.BasicUnitCapacityConstraints(&a2)...)
+	assert.Nil(t, err)
+
+	ag1 := opt.NewGroup(a1, a2)
+	err = ag1.NewSparseConstraint(opt.NetLoadConstraint(&ag1, 7), opt.GroupPositiveCapacityConstraint(&ag1, 10))
+	assert.Nil(t, err)
+
+	res, err := s.SolveLp(ag1, opt.SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDeltaSlice(t, []float64{5, 0, 5, 0, 2, 0, 5, 0}, res.Columns, 0.1)
+}
+
+func testClusterLinkedBusConstraint(t *testing.T, s opt.Solver) {
+	pid1, _ := uuid.NewUUID()
+	pid2, _ := uuid.NewUUID()
+	a1 := opt.NewBasicUnit(pid1, 0.1, 0.1, 0.01, 0, 5, 5, 5, 0)
+	a2 := opt.NewBasicUnit(pid2, 2.0, 2.0, 0.01, 0, 5, 5, 5, 0)
+	err := a1.NewSparseConstraint(opt.BasicUnitCapacityConstraints(&a1)...)
+	assert.Nil(t, err)
+	err = a2.NewSparseConstraint(opt.BasicUnitCapacityConstraints(&a2)...)
+	assert.Nil(t, err)
+
+	ag1 := opt.NewGroup(a1, a2)
+	ag2 := opt.NewGroup(a1)
+	nload := (5 * rand.Float64()) + 5
+	err = ag1.NewSparseConstraint(opt.NetLoadConstraint(&ag1, nload))
+	assert.Nil(t, err)
+
+	cl1 := opt.NewCluster(ag1, ag2)
+	err = cl1.NewSparseConstraint(opt.LinkedBusConstraints(&cl1, pid1)...)
+	assert.Nil(t, err)
+
+	res, err := s.SolveLp(cl1, opt.SolverOptions{})
+	assert.Nil(t, err)
+
+	// the linked unit dispatches the same in both groups
+	assert.InDeltaSlice(t, []float64{5, 0, 5, 0, nload - 5, 0, nload - 5, 0, 5, 0, 5, 0}, res.Columns, 0.1)
+}
+
+func testSeriesDischargeBatteryConstraint(t *testing.T, s opt.Solver) {
+	pid1, _ := uuid.NewUUID()
+	a1 := opt.NewBasicUnit(pid1, 0.1, 0.1, 0.01, 0, 10, 10, 10, 20)
+	err := a1.NewSparseConstraint(opt.BasicUnitCapacityConstraints(&a1)...)
+	assert.Nil(t, err)
+	ag1 := opt.NewGroup(a1)
+
+	nload := 10.0
+	err = ag1.NewSparseConstraint(opt.NetLoadConstraint(&ag1, nload))
+	assert.Nil(t, err)
+
+	s1 := opt.NewSeries(ag1, ag1, ag1, ag1)
+	err = s1.NewSparseConstraint(opt.BatteryInitialEnergyConstraint(&s1, pid1, 20))
+	assert.Nil(t, err)
+	err = s1.NewSparseConstraint(opt.BatteryEnergyConstraint(&s1, pid1, 0.5)...)
+	assert.Nil(t, err)
+
+	res, err := s.SolveLp(s1, opt.SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDeltaSlice(t, []float64{10, 0, 10, 20, 10, 0, 10, 15, 10, 0, 10, 10, 10, 0, 10, 5}, res.Columns, 0.1, "battery positive power not decreasing stored energy")
+}
+
+func testSeriesChargeBatteryConstraint(t *testing.T, s opt.Solver) {
+	pid1, _ := uuid.NewUUID()
+	a1 := opt.NewBasicUnit(pid1, 0.1, 0.1, 0.01, 0, 10, 10, 10, 20)
+	err := a1.NewSparseConstraint(opt.BasicUnitCapacityConstraints(&a1)...)
+	assert.Nil(t, err)
+	ag1 := opt.NewGroup(a1)
+
+	nload := -10.0
+	err = ag1.NewSparseConstraint(opt.NetLoadConstraint(&ag1, nload))
+	assert.Nil(t, err)
+
+	s1 := opt.NewSeries(ag1, ag1, ag1, ag1)
+	err = s1.NewSparseConstraint(opt.BatteryInitialEnergyConstraint(&s1, pid1, 5))
+	assert.Nil(t, err)
+	err = s1.NewSparseConstraint(opt.BatteryEnergyConstraint(&s1, pid1, 0.5)...)
+	assert.Nil(t, err)
+
+	res, err := s.SolveLp(s1, opt.SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDeltaSlice(t, []float64{0, 10, 10, 5, 0, 10, 10, 10, 0, 10, 10, 15, 0, 10, 10, 20}, res.Columns, 0.1, "battery negative power not increasing stored energy")
+}
+
+func testInfeasibleNetLoad(t *testing.T, s opt.Solver) {
+	pid1, _ := uuid.NewUUID()
+	a1 := opt.NewBasicUnit(pid1, 1.0, 2.0, 0.01, 0, 5, 5, 5, 5)
+	ag1 := opt.NewGroup(a1)
+
+	err := ag1.NewSparseConstraint(opt.NetLoadConstraint(&ag1, 10))
+	assert.Nil(t, err)
+
+	res, err := s.SolveLp(ag1, opt.SolverOptions{})
+	assert.Error(t, err)
+	assert.Equal(t, opt.StatusInfeasible, res.Status)
+}
+
+func testSeriesDirectionMip(t *testing.T, s opt.Solver) {
+	// discharge is rewarded more than charge costs, so the relaxation cycles the battery
+	pid1, _ := uuid.NewUUID()
+	pid2, _ := uuid.NewUUID()
+	a1 := opt.NewBasicUnit(pid1, -1, 0.5, 0, 0, 10, 10, 10, 20)
+	err := a1.EnableDirection()
+	assert.Nil(t, err)
+	a2 := opt.NewBasicUnit(pid2, 5, 0, 0, 0, 10, 0, 10, 0)
+	ag1 := opt.NewGroup(a1, a2)
+	err = ag1.NewSparseConstraint(opt.NetLoadConstraint(&ag1, 5))
+	assert.Nil(t, err)
+
+	s1 := opt.NewSeries(ag1, ag1)
+	err = s1.NewSparseConstraint(opt.BatteryInitialEnergyConstraint(&s1, pid1, 2.5))
+	assert.Nil(t, err)
+	err = s1.NewSparseConstraint(opt.BatteryEnergyConstraint(&s1, pid1, 1)...)
+	assert.Nil(t, err)
+
+	res, err := s.SolveMip(s1, opt.SolverOptions{})
+	assert.Nil(t, err)
+	for k := 0; k < 2; k++ {
+		xp, xn := res.Columns[9*k], res.Columns[9*k+1]
+		assert.True(t, xp < 1e-6 || xn < 1e-6, "simultaneous positive and negative power: %v, %v", xp, xn)
+	}
+	assert.InDelta(t, 2.5, res.Columns[0], 0.1)
+	assert.InDelta(t, 5, res.Objective, 0.1)
+}
+
+func testPiecewiseUnitMip(t *testing.T, s opt.Solver) {
+	pid1, _ := uuid.NewUUID()
+	cp := []opt.CriticalPoint{opt.NewCriticalPoint(0, 1), opt.NewCriticalPoint(10, 2), opt.NewCriticalPoint(20, 3)}
+	pu := opt.NewPiecewiseUnit(pid1, cp)
+	ag1 := opt.NewGroup(pu)
+	err := ag1.NewSparseConstraint(opt.NetLoadConstraint(&ag1, -8))
+	assert.Nil(t, err)
+
+	s1 := opt.NewSeries(ag1, ag1)
+	res, err := s.SolveMip(s1, opt.SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDeltaSlice(t, []float64{0, 8, 0}, res.Columns[:3], 0.1)
+	assert.InDelta(t, 1, res.Columns[3]+res.Columns[4], 0.1)
+	assert.InDelta(t, 32, res.Objective, 0.1)
+}
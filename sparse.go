@@ -0,0 +1,225 @@
+package cgc_optimize
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SparseConstraint is a constraint row of the form: Lb <= Sum_k(Value_k * x_Index_k) <= Ub
+//
+// Name tags the row for introspection. Names are qualified with the unit PID, group and
+// time step as the constraint is composed into a Group, Cluster and Series.
+type SparseConstraint struct {
+	Name  string
+	Lb    float64
+	Ub    float64
+	Index []int
+	Value []float64
+}
+
+// SparseLinearProgram is a LinearProgram able to report its constraints without
+// materializing the zero coefficients.
+type SparseLinearProgram interface {
+	LinearProgram
+	SparseConstraints() []SparseConstraint
+}
+
+// NewSparseConstraint returns a bounded constraint with no coefficients.
+func NewSparseConstraint(t_lb float64, t_ub float64) SparseConstraint {
+	return SparseConstraint{"", t_lb, t_ub, []int{}, []float64{}}
+}
+
+// Sparse returns the sparse form of a dense constraint []float64{lb, cons..., ub}
+func Sparse(c []float64) SparseConstraint {
+	sc := NewSparseConstraint(lb(c), ub(c))
+	for i, v := range cons(c) {
+		if v != 0 {
+			sc.Index = append(sc.Index, i)
+			sc.Value = append(sc.Value, v)
+		}
+	}
+	return sc
+}
+
+// Add adds t_val to the coefficient of column t_col.
+func (c *SparseConstraint) Add(t_col int, t_val float64) {
+	for k, i := range c.Index {
+		if i == t_col {
+			c.Value[k] += t_val
+			return
+		}
+	}
+	c.Index = append(c.Index, t_col)
+	c.Value = append(c.Value, t_val)
+}
+
+// Named returns a copy of the constraint tagged with t_name.
+func (c SparseConstraint) Named(t_name string) SparseConstraint {
+	c.Name = t_name
+	return c
+}
+
+// Dense returns the constraint as []float64{lb, cons..., ub} over t_n columns.
+func (c SparseConstraint) Dense(t_n int) []float64 {
+	d := make([]float64, t_n+2)
+	d[0] = c.Lb
+	for k, i := range c.Index {
+		d[i+1] += c.Value[k]
+	}
+	d[t_n+1] = c.Ub
+	return d
+}
+
+// Shift returns a copy of the constraint with every column index offset by t_offset and,
+// if the constraint is named, its name qualified by t_prefix.
+func (c SparseConstraint) Shift(t_offset int, t_prefix string) SparseConstraint {
+	sc := c
+	if sc.Name != "" {
+		sc.Name = t_prefix + sc.Name
+	}
+	sc.Index = make([]int, len(c.Index))
+	for k, i := range c.Index {
+		sc.Index[k] = i + t_offset
+	}
+	sc.Value = append([]float64{}, c.Value...)
+	return sc
+}
+
+// validate returns an error if the constraint references a column outside [0, t_n).
+func (c SparseConstraint) validate(t_n int) error {
+	if len(c.Index) != len(c.Value) {
+		err := fmt.Sprintf("constraint contains %v indices and %v values", len(c.Index), len(c.Value))
+		return errors.New(err)
+	}
+	for _, i := range c.Index {
+		if i < 0 || i >= t_n {
+			err := fmt.Sprintf("constraint references column %v, expected less than: %v", i, t_n)
+			return errors.New(err)
+		}
+	}
+	return nil
+}
+
+// validateSparse validates every constraint in t_c against t_n columns.
+func validateSparse(t_n int, t_c []SparseConstraint) error {
+	for _, c := range t_c {
+		if err := c.validate(t_n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateDense returns the sparse form of each dense constraint in t_c, or an error if
+// a constraint does not span t_n columns.
+func validateDense(t_n int, t_c [][]float64) ([]SparseConstraint, error) {
+	cx := make([]SparseConstraint, 0)
+	for _, c := range t_c {
+		if len(c) != t_n+2 {
+			err := fmt.Sprintf("constraint contains %v columns, expected: %v", len(c), t_n+2)
+			return []SparseConstraint{}, errors.New(err)
+		}
+		cx = append(cx, Sparse(c))
+	}
+	return cx, nil
+}
+
+// densify returns the dense form of each constraint over t_n columns.
+func densify(t_n int, t_c []SparseConstraint) [][]float64 {
+	cx := make([][]float64, 0)
+	for _, c := range t_c {
+		cx = append(cx, c.Dense(t_n))
+	}
+	return cx
+}
+
+// shiftConstraints appends the constraints in t_c, offset by t_offset and qualified by
+// t_prefix, to t_dst.
+func shiftConstraints(t_dst []SparseConstraint, t_c []SparseConstraint, t_offset int, t_prefix string) []SparseConstraint {
+	for _, c := range t_c {
+		t_dst = append(t_dst, c.Shift(t_offset, t_prefix))
+	}
+	return t_dst
+}
+
+// constraintNames returns the name of each constraint, naming untagged rows by index.
+func constraintNames(t_c []SparseConstraint) []string {
+	names := make([]string, len(t_c))
+	for i, c := range t_c {
+		names[i] = c.Name
+		if names[i] == "" {
+			names[i] = fmt.Sprintf("c%v", i)
+		}
+	}
+	return names
+}
+
+// prefixNames returns each name in t_names qualified by t_prefix.
+func prefixNames(t_prefix string, t_names []string) []string {
+	px := make([]string, len(t_names))
+	for i, n := range t_names {
+		px[i] = t_prefix + n
+	}
+	return px
+}
+
+// SparseConstraintsOf returns the constraints of w in sparse form, converting dense rows
+// when w does not implement SparseLinearProgram.
+func SparseConstraintsOf(w LinearProgram) []SparseConstraint {
+	if sw, ok := w.(SparseLinearProgram); ok {
+		return sw.SparseConstraints()
+	}
+
+	cx := make([]SparseConstraint, 0)
+	for _, c := range w.Constraints() {
+		cx = append(cx, Sparse(c))
+	}
+	return cx
+}
+
+// CSR returns the constraints in compressed sparse row form: row bounds, the start of
+// each row in index and value, column indices and coefficients.
+func CSR(t_c []SparseConstraint) (lbs []float64, ubs []float64, start []int, index []int, value []float64) {
+	lbs = make([]float64, len(t_c))
+	ubs = make([]float64, len(t_c))
+	start = make([]int, len(t_c))
+	index = make([]int, 0)
+	value = make([]float64, 0)
+
+	for i, c := range t_c {
+		lbs[i], ubs[i] = c.Lb, c.Ub
+		start[i] = len(index)
+		index = append(index, c.Index...)
+		value = append(value, c.Value...)
+	}
+	return lbs, ubs, start, index, value
+}
+
+// CSC returns the constraints in compressed sparse column form over t_n columns: the
+// start of each column in index and value (with a final entry equal to the number of
+// nonzeros), row indices and coefficients.
+func CSC(t_n int, t_c []SparseConstraint) (start []int, index []int, value []float64) {
+	count := make([]int, t_n+1)
+	for _, c := range t_c {
+		for _, j := range c.Index {
+			count[j+1]++
+		}
+	}
+
+	start = make([]int, t_n+1)
+	for j := 0; j < t_n; j++ {
+		start[j+1] = start[j] + count[j+1]
+	}
+
+	index = make([]int, start[t_n])
+	value = make([]float64, start[t_n])
+	next := append([]int{}, start[:t_n]...)
+	for i, c := range t_c {
+		for k, j := range c.Index {
+			index[next[j]] = i
+			value[next[j]] = c.Value[k]
+			next[j]++
+		}
+	}
+	return start, index, value
+}
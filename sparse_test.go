@@ -0,0 +1,85 @@
+package cgc_optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSparseDenseRoundTrip(t *testing.T) {
+	c := []float64{-1, 0, 2, 0, -3, 4}
+	sc := Sparse(c)
+	assert.Equal(t, SparseConstraint{"", -1, 4, []int{1, 3}, []float64{2, -3}}, sc)
+	assert.Equal(t, c, sc.Dense(4))
+}
+
+func TestSparseConstraintAdd(t *testing.T) {
+	sc := NewSparseConstraint(0, 1)
+	sc.Add(3, 1)
+	sc.Add(1, 2)
+	sc.Add(3, -0.5)
+	assert.Equal(t, []int{3, 1}, sc.Index)
+	assert.Equal(t, []float64{0.5, 2}, sc.Value)
+}
+
+func TestSparseConstraintShift(t *testing.T) {
+	sc := SparseConstraint{"", 0, 1, []int{0, 2}, []float64{1, 2}}
+	shifted := sc.Shift(4, "")
+	assert.Equal(t, []int{4, 6}, shifted.Index)
+	assert.Equal(t, []int{0, 2}, sc.Index, "shift modified the original constraint")
+}
+
+func TestBadSparseConstraint(t *testing.T) {
+	g := NewTestGroup()
+	err := g.NewSparseConstraint(SparseConstraint{"", 0, 1, []int{8}, []float64{1}})
+	assert.Error(t, err)
+
+	err = g.NewSparseConstraint(SparseConstraint{"", 0, 1, []int{1, 2}, []float64{1}})
+	assert.Error(t, err)
+}
+
+func TestSeriesSparseConstraints(t *testing.T) {
+	pid1, _ := uuid.NewUUID()
+	inf := math.Inf(1)
+	a1 := NewBasicUnit(pid1, 1, 2, 3, 4, inf, inf, inf, inf)
+	err := a1.NewSparseConstraint(BasicUnitCapacityConstraints(&a1)...)
+	assert.Nil(t, err)
+
+	g := NewGroup(a1)
+	cl := NewCluster(g)
+	s := NewSeries(cl, cl)
+
+	sc := s.SparseConstraints()
+	assert.Len(t, sc, 4)
+	assert.Equal(t, []int{4, 6}, sc[2].Index)
+	assert.Equal(t, []int{5, 6}, sc[3].Index)
+	assert.Equal(t, densify(s.ColumnSize(), sc), s.Constraints())
+}
+
+func TestCSR(t *testing.T) {
+	cx := []SparseConstraint{
+		{"", 0, 1, []int{0, 2}, []float64{1, 2}},
+		{"", -1, 0, []int{1}, []float64{3}},
+	}
+
+	lbs, ubs, start, index, value := CSR(cx)
+	assert.Equal(t, []float64{0, -1}, lbs)
+	assert.Equal(t, []float64{1, 0}, ubs)
+	assert.Equal(t, []int{0, 2}, start)
+	assert.Equal(t, []int{0, 2, 1}, index)
+	assert.Equal(t, []float64{1, 2, 3}, value)
+}
+
+func TestCSC(t *testing.T) {
+	cx := []SparseConstraint{
+		{"", 0, 1, []int{0, 2}, []float64{1, 2}},
+		{"", -1, 0, []int{2, 1}, []float64{3, 4}},
+	}
+
+	start, index, value := CSC(4, cx)
+	assert.Equal(t, []int{0, 1, 2, 4, 4}, start)
+	assert.Equal(t, []int{0, 1, 0, 1}, index)
+	assert.Equal(t, []float64{1, 4, 2, 3}, value)
+}
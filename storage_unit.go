@@ -0,0 +1,363 @@
+package cgc_optimize
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// Degrader is implemented by units whose dispatch wears the unit.
+type Degrader interface {
+	// DegradationCoefficients returns the part of the cost coefficient of each unit column
+	// due to wear.
+	DegradationCoefficients() []float64
+}
+
+// StorageUnit is a battery whose cost includes its degradation. Discharge is real positive
+// power and charge real negative power. Each unit of energy moved through the battery is
+// charged a throughput cost, and EnableDepthCost adds a piecewise cost of the energy
+// discharged by its depth of discharge.
+type StorageUnit struct {
+	pid          uuid.UUID
+	coefficients []float64
+	degradation  []float64
+	bounds       [][2]float64
+	constraints  []SparseConstraint
+	depth        []float64 // width of each depth of discharge segment
+}
+
+var _ Unit = StorageUnit{}
+var _ Degrader = StorageUnit{}
+
+// NewStorageUnit returns a configured storage unit struct. Stored energy is tracked across
+// a series with the Battery constraints.
+//
+// Cp: Cost coefficient for real positive power
+// Cn: Cost coefficient for real negative power
+// Cc: Cost coefficient for real capacity
+// Ct: Throughput cost, added to the cost of both real positive and real negative power
+//
+// XpUb: Upper bound for real positive power decision variable
+// XnUb: Upper bound for real negative power decision variable (positive value)
+// XcUb: Upper bound for real capacity decision variable
+// XeUb: Upper bound for stored energy, the usable energy capacity
+func NewStorageUnit(pid uuid.UUID, Cp float64, Cn float64, Cc float64, Ct float64, XpUb float64, XnUb float64, XcUb float64, XeUb float64) StorageUnit {
+	coefficients := []float64{Cp + Ct, Cn + Ct, Cc, 0}
+	degradation := []float64{Ct, Ct, 0, 0}
+	bounds := [][2]float64{{0, XpUb}, {0, XnUb}, {0, XcUb}, {0, XeUb}}
+
+	return StorageUnit{pid, coefficients, degradation, bounds, []SparseConstraint{}, []float64{}}
+}
+
+// EnableDepthCost adds a depth of discharge cost to the energy discharged, for time steps
+// of length t_step. The cost curve is given by critical points of the depth of discharge,
+// the stored energy below XeUb, and the cost of a cycle discharging the full battery to
+// that depth, beginning at a depth of 0 and ending at or beyond XeUb. The stored energy is
+// split into a segment for each part of the curve, shallowest first, each with its own
+// discharge, charge and stored energy columns, with rows of the form:
+//
+// Xp - Sum_k(Xp_k) = 0, Xn - Sum_k(Xn_k) = 0 and Xe - Sum_k(Xe_k) = 0
+//
+// Energy discharged from segment k is charged the slope of its part of the curve, its
+// marginal cycle cost. The slopes must not decrease, as a deep cycle wears a battery more
+// than shallow ones, so that shallow segments discharge first without binary columns. The
+// stored energy of each segment is carried across a series by StorageDepthEnergyConstraints.
+// Enabling depth cost twice returns an error.
+func (u *StorageUnit) EnableDepthCost(C []CriticalPoint, t_step float64) error {
+	if len(u.depth) > 0 {
+		err := fmt.Sprintf("unit %v depth cost is already enabled", u.pid)
+		return errors.New(err)
+	}
+
+	xeUb := u.bounds[u.StoredEnergyLoc()[0]][1]
+	switch {
+	case t_step <= 0:
+		err := fmt.Sprintf("depth cost time step is %v, expected a positive duration", t_step)
+		return errors.New(err)
+	case math.IsInf(xeUb, 1):
+		err := fmt.Sprintf("depth cost requires a finite stored energy bound, found XeUb: %v", xeUb)
+		return errors.New(err)
+	case len(C) < 2:
+		err := fmt.Sprintf("depth cost has %v critical points, expected at least 2", len(C))
+		return errors.New(err)
+	case C[0].val != 0:
+		err := fmt.Sprintf("depth cost begins at depth %v, expected: 0", C[0].val)
+		return errors.New(err)
+	case C[len(C)-1].val < xeUb:
+		err := fmt.Sprintf("depth cost ends at depth %v, expected at least XeUb: %v", C[len(C)-1].val, xeUb)
+		return errors.New(err)
+	}
+
+	slope := math.Inf(-1)
+	for k := 1; k < len(C); k++ {
+		width := C[k].val - C[k-1].val
+		if width <= 0 {
+			err := fmt.Sprintf("depth cost critical point %v is not deeper than the last", k)
+			return errors.New(err)
+		}
+		s := (C[k].cost - C[k-1].cost) / width
+		if s < slope {
+			err := fmt.Sprintf("depth cost slope decreases at critical point %v", k)
+			return errors.New(err)
+		}
+		slope = s
+	}
+
+	discharge := NewSparseConstraint(0, 0).Named("depth_discharge")
+	discharge.Add(u.RealPositivePowerLoc()[0], 1)
+	charge := NewSparseConstraint(0, 0).Named("depth_charge")
+	charge.Add(u.RealNegativePowerLoc()[0], 1)
+	energy := NewSparseConstraint(0, 0).Named("depth_energy")
+	energy.Add(u.StoredEnergyLoc()[0], 1)
+
+	// segments deeper than XeUb hold no energy and are left out
+	for k := 1; k < len(C) && C[k-1].val < xeUb; k++ {
+		width := math.Min(C[k].val, xeUb) - C[k-1].val
+		s := t_step * (C[k].cost - C[k-1].cost) / (C[k].val - C[k-1].val)
+
+		j := u.ColumnSize()
+		discharge.Add(j, -1)
+		charge.Add(j+1, -1)
+		energy.Add(j+2, -1)
+		u.coefficients = append(u.coefficients, s, 0, 0)
+		u.degradation = append(u.degradation, s, 0, 0)
+		u.bounds = append(u.bounds, [2]float64{0, math.Inf(1)}, [2]float64{0, math.Inf(1)}, [2]float64{0, width})
+		u.depth = append(u.depth, width)
+	}
+
+	u.constraints = append(u.constraints, discharge, charge, energy)
+	return nil
+}
+
+func (u StorageUnit) PID() uuid.UUID {
+	return u.pid
+}
+
+func (u StorageUnit) CostCoefficients() []float64 {
+	return u.coefficients
+}
+
+// DegradationCoefficients returns the throughput cost of the power columns and the depth
+// cost of the segment discharge columns, 0 for every other column.
+func (u StorageUnit) DegradationCoefficients() []float64 {
+	return u.degradation
+}
+
+func (u StorageUnit) ColumnSize() int {
+	return len(u.coefficients)
+}
+
+// ColumnNames returns the name of each column: xp, xn, xc, xe and, with depth cost
+// enabled, xp<k>, xn<k> and xe<k> for each segment k
+func (u StorageUnit) ColumnNames() []string {
+	names := []string{"xp", "xn", "xc", "xe"}
+	for k := range u.depth {
+		names = append(names, fmt.Sprintf("xp%v", k), fmt.Sprintf("xn%v", k), fmt.Sprintf("xe%v", k))
+	}
+	return names
+}
+
+func (u StorageUnit) Integrality() []int {
+	return make([]int, u.ColumnSize())
+}
+
+func (u *StorageUnit) NewConstraint(t_c ...[]float64) error {
+	cx, err := validateDense(u.ColumnSize(), t_c)
+	if err != nil {
+		return err
+	}
+
+	// if no errors: add constraints to unit
+	u.constraints = append(u.constraints, cx...)
+	return nil
+}
+
+func (u *StorageUnit) NewSparseConstraint(t_c ...SparseConstraint) error {
+	if err := validateSparse(u.ColumnSize(), t_c); err != nil {
+		return err
+	}
+
+	u.constraints = append(u.constraints, t_c...)
+	return nil
+}
+
+func (u StorageUnit) Constraints() [][]float64 {
+	return densify(u.ColumnSize(), u.constraints)
+}
+
+func (u StorageUnit) SparseConstraints() []SparseConstraint {
+	return u.constraints
+}
+
+func (u StorageUnit) Bounds() [][2]float64 {
+	return u.bounds
+}
+
+func (u StorageUnit) RealPositivePowerLoc() []int {
+	return []int{0}
+}
+
+func (u StorageUnit) RealNegativePowerLoc() []int {
+	return []int{1}
+}
+
+func (u StorageUnit) RealCapacityLoc() []int {
+	return []int{2}
+}
+
+func (u StorageUnit) StoredEnergyLoc() []int {
+	return []int{3}
+}
+
+// DepthPositivePowerLoc returns the location of the discharge column of each depth
+// segment, shallowest first, empty unless depth cost is enabled.
+func (u StorageUnit) DepthPositivePowerLoc() []int {
+	return u.depthLoc(0)
+}
+
+// DepthNegativePowerLoc returns the location of the charge column of each depth segment.
+func (u StorageUnit) DepthNegativePowerLoc() []int {
+	return u.depthLoc(1)
+}
+
+// DepthEnergyLoc returns the location of the stored energy column of each depth segment.
+func (u StorageUnit) DepthEnergyLoc() []int {
+	return u.depthLoc(2)
+}
+
+// depthLoc returns the t_i-th column of each depth segment
+func (u StorageUnit) depthLoc(t_i int) []int {
+	loc := make([]int, 0)
+	for k := range u.depth {
+		loc = append(loc, 4+3*k+t_i)
+	}
+	return loc
+}
+
+// DegradationTerm returns the degradation cost of the units t_pids of t_w as an objective
+// term. Units that do not implement Degrader have no degradation cost.
+func DegradationTerm(t_name string, t_w unitCoster, t_pids ...uuid.UUID) ObjectiveTerm {
+	o := NewObjectiveTerm(t_name)
+	for _, pid := range t_pids {
+		dc := make([]float64, 0)
+		loc := t_w.PidLoc(pid, func(u Unit) []int {
+			d, ok := u.(Degrader)
+			if !ok {
+				return []int{}
+			}
+			dc = append(dc, d.DegradationCoefficients()...)
+			return allLoc(u)
+		})
+		for k, j := range loc {
+			if dc[k] != 0 {
+				o.Add(j, dc[k])
+			}
+		}
+	}
+	return o
+}
+
+// Constraints
+
+func StorageUnitCapacityConstraints(u *StorageUnit) []SparseConstraint {
+	cx := make([]SparseConstraint, 0)
+	cx = append(cx, StorageUnitPositiveCapacityConstraint(u))
+	cx = append(cx, StorageUnitNegativeCapacityConstraint(u))
+	return cx
+}
+
+func StorageUnitPositiveCapacityConstraint(u *StorageUnit) SparseConstraint {
+	xp := u.RealPositivePowerLoc()[0]
+	xc := u.RealCapacityLoc()[0]
+
+	cp := NewSparseConstraint(0, math.Inf(1)).Named("positive_capacity")
+	cp.Add(xp, -1)
+	cp.Add(xc, 1)
+	return cp
+}
+
+func StorageUnitNegativeCapacityConstraint(u *StorageUnit) SparseConstraint {
+	xn := u.RealNegativePowerLoc()[0]
+	xc := u.RealCapacityLoc()[0]
+
+	cn := NewSparseConstraint(0, math.Inf(1)).Named("negative_capacity")
+	cn.Add(xn, -1)
+	cn.Add(xc, 1)
+	return cn
+}
+
+// StorageDepthEnergyConstraints returns the energy balance of each depth segment k of
+// storage unit t_pid, as in BatteryEnergyEfficiencyConstraint:
+//
+// (1-t_sd)*e_k_ti - (p_k_ti/t_etaD)*t + (t_etaC*n_k_ti)*t = e_k_t(i+1)
+//
+// and a constraint of the form 0 <= e_k_end <= XeUb_k on the stored energy of each segment
+// after the final time step. It returns an error if the unit does not have depth cost
+// enabled, or an efficiency or the self discharge is out of range.
+func StorageDepthEnergyConstraints(t_se *Series, t_pid uuid.UUID, t_tstep float64, t_etaC float64, t_etaD float64, t_sd float64) ([]SparseConstraint, error) {
+	if err := validateStorageLosses(t_etaC, t_etaD, t_sd); err != nil {
+		return []SparseConstraint{}, err
+	}
+
+	pLoc := t_se.PidLoc(t_pid, depthPositivePowerLoc)
+	nLoc := t_se.PidLoc(t_pid, depthNegativePowerLoc)
+	eLoc := t_se.PidLoc(t_pid, depthEnergyLoc)
+	steps := len(t_se.StoredEnergyPidLoc(t_pid))
+	if len(eLoc) == 0 || steps == 0 || len(eLoc)%steps != 0 {
+		err := fmt.Sprintf("storage unit %v does not have depth cost enabled", t_pid)
+		return []SparseConstraint{}, errors.New(err)
+	}
+
+	n := len(eLoc) / steps
+	b := t_se.Bounds()
+	cx := make([]SparseConstraint, 0)
+	for k := 0; k < n; k++ {
+		for i := 0; i < steps-1; i++ {
+			j := i*n + k
+			c := NewSparseConstraint(0, 0).Named(fmt.Sprintf("%v.depth_balance%v.t%v", t_pid, k, i))
+			c.Add(pLoc[j], -t_tstep/t_etaD)
+			c.Add(nLoc[j], t_etaC*t_tstep)
+			c.Add(eLoc[j], 1-t_sd)
+			c.Add(eLoc[j+n], -1)
+			cx = append(cx, c)
+		}
+
+		j := (steps-1)*n + k
+		c := NewSparseConstraint(0, b[eLoc[j]][1]).Named(fmt.Sprintf("%v.depth_final%v", t_pid, k))
+		c.Add(pLoc[j], -t_tstep/t_etaD)
+		c.Add(nLoc[j], t_etaC*t_tstep)
+		c.Add(eLoc[j], 1-t_sd)
+		cx = append(cx, c)
+	}
+	return cx, nil
+}
+
+// depthSegmenter is implemented by units whose stored energy is split into depth segments
+type depthSegmenter interface {
+	DepthPositivePowerLoc() []int
+	DepthNegativePowerLoc() []int
+	DepthEnergyLoc() []int
+}
+
+func depthPositivePowerLoc(u Unit) []int {
+	if d, ok := u.(depthSegmenter); ok {
+		return d.DepthPositivePowerLoc()
+	}
+	return []int{}
+}
+
+func depthNegativePowerLoc(u Unit) []int {
+	if d, ok := u.(depthSegmenter); ok {
+		return d.DepthNegativePowerLoc()
+	}
+	return []int{}
+}
+
+func depthEnergyLoc(u Unit) []int {
+	if d, ok := u.(depthSegmenter); ok {
+		return d.DepthEnergyLoc()
+	}
+	return []int{}
+}
@@ -0,0 +1,189 @@
+package cgc_optimize
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewStorageUnit(t *testing.T) {
+	pid, _ := uuid.NewUUID()
+	u := NewStorageUnit(pid, 1, 2, 3, 0.5, 10, 20, 30, 40)
+
+	assert.Equal(t, pid, u.PID())
+	assert.Equal(t, []float64{1.5, 2.5, 3, 0}, u.CostCoefficients())
+	assert.Equal(t, []float64{0.5, 0.5, 0, 0}, u.DegradationCoefficients())
+	assert.Equal(t, [][2]float64{{0, 10}, {0, 20}, {0, 30}, {0, 40}}, u.Bounds())
+	assert.Equal(t, []string{"xp", "xn", "xc", "xe"}, u.ColumnNames())
+	assert.Equal(t, []int{0, 0, 0, 0}, u.Integrality())
+	assert.Equal(t, []int{3}, u.StoredEnergyLoc())
+	assert.Empty(t, u.DepthEnergyLoc())
+
+	err := u.NewSparseConstraint(StorageUnitCapacityConstraints(&u)...)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]float64{
+		{0, -1, 0, 1, 0, math.Inf(1)},
+		{0, 0, -1, 1, 0, math.Inf(1)}}, u.Constraints())
+}
+
+func TestStorageUnitEnableDepthCost(t *testing.T) {
+	pid, _ := uuid.NewUUID()
+	u := NewStorageUnit(pid, 0, 0, 0, 0, 10, 10, 10, 10)
+
+	err := u.EnableDepthCost([]CriticalPoint{NewCriticalPoint(0, 0), NewCriticalPoint(4, 1), NewCriticalPoint(10, 7)}, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{4, 7}, u.DepthPositivePowerLoc())
+	assert.Equal(t, []int{5, 8}, u.DepthNegativePowerLoc())
+	assert.Equal(t, []int{6, 9}, u.DepthEnergyLoc())
+	assert.Equal(t, []string{"xp", "xn", "xc", "xe", "xp0", "xn0", "xe0", "xp1", "xn1", "xe1"}, u.ColumnNames())
+	assert.Equal(t, []float64{0, 0, 0, 0, 0.5, 0, 0, 2, 0, 0}, u.CostCoefficients())
+	assert.Equal(t, u.CostCoefficients(), u.DegradationCoefficients())
+	assert.Equal(t, [2]float64{0, 4}, u.Bounds()[6])
+	assert.Equal(t, [2]float64{0, 6}, u.Bounds()[9])
+	assert.Equal(t, [][]float64{
+		{0, 1, 0, 0, 0, -1, 0, 0, -1, 0, 0, 0},
+		{0, 0, 1, 0, 0, 0, -1, 0, 0, -1, 0, 0},
+		{0, 0, 0, 0, 1, 0, 0, -1, 0, 0, -1, 0}}, u.Constraints())
+
+	err = u.EnableDepthCost([]CriticalPoint{NewCriticalPoint(0, 0), NewCriticalPoint(10, 1)}, 1)
+	assert.Error(t, err, "depth cost enabled twice")
+
+	// segments are cut at the stored energy bound
+	v := NewStorageUnit(pid, 0, 0, 0, 0, 10, 10, 10, 10)
+	err = v.EnableDepthCost([]CriticalPoint{NewCriticalPoint(0, 0), NewCriticalPoint(10, 1), NewCriticalPoint(20, 5)}, 1)
+	assert.Nil(t, err)
+	assert.Len(t, v.DepthEnergyLoc(), 1)
+	v = NewStorageUnit(pid, 0, 0, 0, 0, 10, 10, 10, 10)
+	err = v.EnableDepthCost([]CriticalPoint{NewCriticalPoint(0, 0), NewCriticalPoint(5, 1), NewCriticalPoint(20, 4)}, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, [2]float64{0, 5}, v.Bounds()[v.DepthEnergyLoc()[1]])
+
+	for _, cx := range [][]CriticalPoint{
+		{NewCriticalPoint(0, 0)},
+		{NewCriticalPoint(1, 0), NewCriticalPoint(10, 1)},
+		{NewCriticalPoint(0, 0), NewCriticalPoint(8, 1)},
+		{NewCriticalPoint(0, 0), NewCriticalPoint(0, 1), NewCriticalPoint(10, 1)},
+		{NewCriticalPoint(0, 0), NewCriticalPoint(5, 5), NewCriticalPoint(10, 6)},
+	} {
+		v := NewStorageUnit(pid, 0, 0, 0, 0, 10, 10, 10, 10)
+		assert.Error(t, v.EnableDepthCost(cx, 1), "invalid depth cost accepted: %v", cx)
+		assert.Equal(t, 4, v.ColumnSize())
+	}
+
+	v = NewStorageUnit(pid, 0, 0, 0, 0, 10, 10, 10, math.Inf(1))
+	assert.Error(t, v.EnableDepthCost([]CriticalPoint{NewCriticalPoint(0, 0), NewCriticalPoint(10, 1)}, 1))
+
+	v = NewStorageUnit(pid, 0, 0, 0, 0, 10, 10, 10, 10)
+	assert.Error(t, v.EnableDepthCost([]CriticalPoint{NewCriticalPoint(0, 0), NewCriticalPoint(10, 1)}, 0))
+}
+
+// newTestStorageSeries returns a series of three one hour steps serving a load of t_nl
+// from a grid unit, priced by t_price, and storage unit u holding t_e at the first step.
+func newTestStorageSeries(t *testing.T, u StorageUnit, t_nl float64, t_price []float64, t_e float64) (Series, uuid.UUID) {
+	gridPID, _ := uuid.NewUUID()
+	grid := NewBasicUnit(gridPID, 0, 0, 0, 0, math.Inf(1), 0, 0, 0)
+	g := NewGroup(grid, u)
+	err := g.NewSparseConstraint(NetLoadConstraint(&g, t_nl))
+	assert.Nil(t, err)
+	cl := NewCluster(g)
+
+	se := NewSeriesFromTemplate(&cl, 3)
+	assert.Nil(t, se.SetCostProfile(se.RealPositivePowerPidLoc(gridPID), t_price))
+	assert.Nil(t, se.NewSparseConstraint(BatteryEnergyConstraint(&se, u.PID(), 1)...))
+	fc, err := BatteryFinalEnergyConstraint(&se, u.PID(), 1, 1, 1, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, se.NewSparseConstraint(fc))
+	assert.Nil(t, se.NewSparseConstraint(BatteryInitialEnergyConstraint(&se, u.PID(), t_e)))
+	if len(u.DepthEnergyLoc()) > 0 {
+		dx, err := StorageDepthEnergyConstraints(&se, u.PID(), 1, 1, 1, 0)
+		assert.Nil(t, err)
+		assert.Nil(t, se.NewSparseConstraint(dx...))
+	}
+	return se, gridPID
+}
+
+func TestStorageUnitThroughputSolve(t *testing.T) {
+	pid, _ := uuid.NewUUID()
+	price := []float64{1, 1.05, 1.05}
+
+	// a throughput cost below the price spread leaves the battery cycling
+	u := NewStorageUnit(pid, 0, 0, 0, 0.01, 10, 10, 10, 10)
+	se, _ := newTestStorageSeries(t, u, 5, price, 0)
+	res, err := NativeSolver{}.SolveLp(se, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 15+0.01*20, res.Objective, 1e-6)
+
+	o := NewObjective(&se)
+	assert.Nil(t, o.AddTerm(DegradationTerm("degradation", &se, pid), 1, 0))
+	assert.InDelta(t, 0.01*20, o.Evaluate(res.Columns)["degradation"], 1e-6)
+
+	// a throughput cost above it stops the trade
+	u = NewStorageUnit(pid, 0, 0, 0, 0.1, 10, 10, 10, 10)
+	se, _ = newTestStorageSeries(t, u, 5, price, 0)
+	res, err = NativeSolver{}.SolveLp(se, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 5+2*5*1.05, res.Objective, 1e-6)
+
+	srx, err := se.Decode(res.Columns)
+	assert.Nil(t, err)
+	for _, r := range UnitSeries(srx, pid) {
+		assert.InDelta(t, 0, r.RealPositivePower, 1e-6)
+		assert.InDelta(t, 0, r.RealNegativePower, 1e-6)
+	}
+}
+
+func TestStorageUnitDepthCostSolve(t *testing.T) {
+	pid, _ := uuid.NewUUID()
+	depth := []CriticalPoint{NewCriticalPoint(0, 0), NewCriticalPoint(5, 0), NewCriticalPoint(10, 15)}
+	price := []float64{2, 2, 1}
+
+	u := NewStorageUnit(pid, 0, 0, 0, 0, 10, 10, 10, 10)
+	se, _ := newTestStorageSeries(t, u, 10, price, 10)
+	res, err := NativeSolver{}.SolveLp(se, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 50-2*10, res.Objective, 1e-6)
+
+	// discharging the deep half of the battery costs more than the price it saves, so only
+	// the shallow half is discharged
+	u = NewStorageUnit(pid, 0, 0, 0, 0, 10, 10, 10, 10)
+	err = u.EnableDepthCost(depth, 1)
+	assert.Nil(t, err)
+	se, _ = newTestStorageSeries(t, u, 10, price, 10)
+	res, err = NativeSolver{}.SolveLp(se, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 50-2*5, res.Objective, 1e-6)
+
+	srx, err := se.Decode(res.Columns)
+	assert.Nil(t, err)
+	ux := UnitSeries(srx, pid)
+	assert.InDelta(t, 5, ux[0].RealPositivePower+ux[1].RealPositivePower, 1e-6)
+	assert.InDelta(t, 5, ux[2].StoredEnergy-ux[2].RealPositivePower, 1e-6)
+
+	// resting deep is not charged, only the energy discharged
+	u = NewStorageUnit(pid, 0, 0, 0, 0, 10, 10, 10, 10)
+	err = u.EnableDepthCost(depth, 1)
+	assert.Nil(t, err)
+	se, _ = newTestStorageSeries(t, u, 10, []float64{1, 1, 1}, 2)
+	res, err = NativeSolver{}.SolveLp(se, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 30-2, res.Objective, 1e-6)
+
+	// a deep cycle is charged for the energy taken from the deep segment
+	se, _ = newTestStorageSeries(t, u, 10, []float64{1, 5, 5}, 2)
+	res, err = NativeSolver{}.SolveLp(se, SolverOptions{})
+	assert.Nil(t, err)
+	assert.InDelta(t, 18+50+3*5, res.Objective, 1e-6)
+
+	o := NewObjective(&se)
+	assert.Nil(t, o.AddTerm(DegradationTerm("degradation", &se, pid), 1, 0))
+	assert.InDelta(t, 3*5, o.Evaluate(res.Columns)["degradation"], 1e-6)
+
+	_, err = StorageDepthEnergyConstraints(&se, pid, 1, 0, 1, 0)
+	assert.Error(t, err)
+	v := NewStorageUnit(pid, 0, 0, 0, 0, 10, 10, 10, 10)
+	se, _ = newTestStorageSeries(t, v, 10, price, 10)
+	_, err = StorageDepthEnergyConstraints(&se, pid, 1, 1, 1, 0)
+	assert.Error(t, err, "storage unit without depth cost accepted")
+}
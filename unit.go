@@ -13,18 +13,27 @@ type Unit interface {
 	CostCoefficients() []float64
 	Bounds() [][2]float64
 	Constraints() [][]float64
+	SparseConstraints() []SparseConstraint
 	ColumnSize() int
+	ColumnNames() []string
+	Integrality() []int
 
-	RealPower() float64
+	RealPositivePowerLoc() []int
+	RealNegativePowerLoc() []int
+	RealCapacityLoc() []int
+	StoredEnergyLoc() []int
 }
 
 type BasicUnit struct {
 	pid          uuid.UUID
 	coefficients []float64
 	bounds       [][2]float64
-	constraints  [][]float64
+	constraints  []SparseConstraint
+	direction    bool
 }
 
+var _ Unit = BasicUnit{}
+
 // NewBasicUnit returns a configured unit struct.
 //
 // Cp: Cost coefficient for real positive power
@@ -40,7 +49,7 @@ func NewBasicUnit(pid uuid.UUID, Cp float64, Cn float64, Cc float64, Ce float64,
 	coefficients := []float64{Cp, Cn, Cc, Ce}
 	bounds := [][2]float64{{0, XpUb}, {0, XnUb}, {0, XcUb}, {0, XeUb}}
 
-	return BasicUnit{pid, coefficients, bounds, [][]float64{}}
+	return BasicUnit{pid, coefficients, bounds, []SparseConstraint{}, false}
 }
 
 func (u BasicUnit) PID() uuid.UUID {
@@ -52,17 +61,60 @@ func (u BasicUnit) CostCoefficients() []float64 {
 }
 
 func (u BasicUnit) ColumnSize() int {
-	return 4
+	return len(u.coefficients)
+}
+
+// ColumnNames returns the name of each column: xp, xn, xc, xe and, with direction
+// enabled, xd
+func (u BasicUnit) ColumnNames() []string {
+	if u.direction {
+		return []string{"xp", "xn", "xc", "xe", "xd"}
+	}
+	return []string{"xp", "xn", "xc", "xe"}
+}
+
+// Integrality returns the integer mask of the unit columns. Only the direction column
+// is integer.
+func (u BasicUnit) Integrality() []int {
+	mask := make([]int, u.ColumnSize())
+	for _, i := range u.DirectionLoc() {
+		mask[i] = 1
+	}
+	return mask
+}
+
+// EnableDirection adds a binary direction column, Xd, and constraints that allow only
+// one of real positive and real negative power to be nonzero:
+//
+// Xp <= XpUb * Xd
+// Xn <= XnUb * (1 - Xd)
+//
+// The power upper bounds serve as big-M, so both must be finite, and a series may not
+// raise them with SetUpperBoundProfile. The unit must then be solved as a MIP. Enabling
+// direction twice has no further effect.
+func (u *BasicUnit) EnableDirection() error {
+	if u.direction {
+		return nil
+	}
+
+	xpUb := u.bounds[u.RealPositivePowerLoc()[0]][1]
+	xnUb := u.bounds[u.RealNegativePowerLoc()[0]][1]
+	if math.IsInf(xpUb, 1) || math.IsInf(xnUb, 1) {
+		err := fmt.Sprintf("direction requires finite power bounds, found XpUb: %v, XnUb: %v", xpUb, xnUb)
+		return errors.New(err)
+	}
+
+	u.direction = true
+	u.coefficients = append(u.coefficients, 0)
+	u.bounds = append(u.bounds, [2]float64{0, 1})
+	u.constraints = append(u.constraints, BasicUnitDirectionConstraints(u)...)
+	return nil
 }
 
 func (u *BasicUnit) NewConstraint(t_c ...[]float64) error {
-	cx := make([][]float64, 0)
-	for _, c := range t_c {
-		if len(c) != u.ColumnSize()+2 {
-			err := fmt.Sprintf("constraint contains %v columns, expected: %v", len(c), u.ColumnSize()+2)
-			return errors.New(err)
-		}
-		cx = append(cx, c)
+	cx, err := validateDense(u.ColumnSize(), t_c)
+	if err != nil {
+		return err
 	}
 
 	// if no errors: add constraints to unit
@@ -70,7 +122,20 @@ func (u *BasicUnit) NewConstraint(t_c ...[]float64) error {
 	return nil
 }
 
+func (u *BasicUnit) NewSparseConstraint(t_c ...SparseConstraint) error {
+	if err := validateSparse(u.ColumnSize(), t_c); err != nil {
+		return err
+	}
+
+	u.constraints = append(u.constraints, t_c...)
+	return nil
+}
+
 func (u BasicUnit) Constraints() [][]float64 {
+	return densify(u.ColumnSize(), u.constraints)
+}
+
+func (u BasicUnit) SparseConstraints() []SparseConstraint {
 	return u.constraints
 }
 
@@ -94,35 +159,78 @@ func (u BasicUnit) StoredEnergyLoc() []int {
 	return []int{3}
 }
 
+// DirectionLoc returns the location of the direction column, empty unless direction is
+// enabled.
+func (u BasicUnit) DirectionLoc() []int {
+	if u.direction {
+		return []int{4}
+	}
+	return []int{}
+}
+
+// directionLimiter is implemented by units whose power columns may be limited by a
+// direction binary
+type directionLimiter interface {
+	directionLimits() map[int]float64
+}
+
+// directionLimits returns the big-M of the power columns limited by the direction
+// binary, empty unless direction is enabled.
+func (u BasicUnit) directionLimits() map[int]float64 {
+	m := make(map[int]float64)
+	if u.direction {
+		for _, i := range append(u.RealPositivePowerLoc(), u.RealNegativePowerLoc()...) {
+			m[i] = u.bounds[i][1]
+		}
+	}
+	return m
+}
+
 // Constraints
 
-func BasicUnitCapacityConstraints(u *BasicUnit) [][]float64 {
-	cx := make([][]float64, 0)
+func BasicUnitCapacityConstraints(u *BasicUnit) []SparseConstraint {
+	cx := make([]SparseConstraint, 0)
 	cx = append(cx, BasicUnitPositiveCapacityConstraint(u))
 	cx = append(cx, BasicUnitNegativeCapacityConstraint(u))
 	return cx
 }
 
-func BasicUnitPositiveCapacityConstraint(u *BasicUnit) []float64 {
+func BasicUnitPositiveCapacityConstraint(u *BasicUnit) SparseConstraint {
 	xp := u.RealPositivePowerLoc()[0]
 	xc := u.RealCapacityLoc()[0]
 
-	cp := make([]float64, u.ColumnSize())
-	cp[xp] = -1
-	cp[xc] = 1
-
-	cp = boundConstraint(cp, 0, math.Inf(1))
+	cp := NewSparseConstraint(0, math.Inf(1)).Named("positive_capacity")
+	cp.Add(xp, -1)
+	cp.Add(xc, 1)
 	return cp
 }
 
-func BasicUnitNegativeCapacityConstraint(u *BasicUnit) []float64 {
+func BasicUnitNegativeCapacityConstraint(u *BasicUnit) SparseConstraint {
 	xn := u.RealNegativePowerLoc()[0]
 	xc := u.RealCapacityLoc()[0]
 
-	cn := make([]float64, u.ColumnSize())
-	cn[xn] = -1
-	cn[xc] = 1
-
-	cn = boundConstraint(cn, 0, math.Inf(1))
+	cn := NewSparseConstraint(0, math.Inf(1)).Named("negative_capacity")
+	cn.Add(xn, -1)
+	cn.Add(xc, 1)
 	return cn
 }
+
+// BasicUnitDirectionConstraints returns constraints of the form: Xp - XpUb*Xd <= 0 and
+// Xn + XnUb*Xd <= XnUb. Direction must be enabled on the unit.
+func BasicUnitDirectionConstraints(u *BasicUnit) []SparseConstraint {
+	xp := u.RealPositivePowerLoc()[0]
+	xn := u.RealNegativePowerLoc()[0]
+	xd := u.DirectionLoc()[0]
+	xpUb := u.bounds[xp][1]
+	xnUb := u.bounds[xn][1]
+
+	cp := NewSparseConstraint(math.Inf(-1), 0).Named("direction_positive")
+	cp.Add(xp, 1)
+	cp.Add(xd, -xpUb)
+
+	cn := NewSparseConstraint(math.Inf(-1), xnUb).Named("direction_negative")
+	cn.Add(xn, 1)
+	cn.Add(xd, xnUb)
+
+	return []SparseConstraint{cp, cn}
+}
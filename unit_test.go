@@ -111,7 +111,7 @@ func TestUnitCapacityConstraint(t *testing.T) {
 
 	ucc := BasicUnitCapacityConstraints(&a)
 	for _, c := range ucc {
-		err := a.NewConstraint(c)
+		err := a.NewSparseConstraint(c)
 		assert.Nil(t, err)
 	}
 
@@ -120,3 +120,69 @@ func TestUnitCapacityConstraint(t *testing.T) {
 	assert.Equal(t, []float64{0, 0, -1, 1, 0, math.Inf(1)}, ac[1], "negative capacity constraint malformed")
 	assert.Len(t, ac, 2)
 }
+
+func TestUnitDirection(t *testing.T) {
+	pid, _ := uuid.NewUUID()
+	a := NewBasicUnit(pid, 1, 1, 0.1, 0, 10, 5, 10, 0)
+	assert.Equal(t, []int{0, 0, 0, 0}, a.Integrality())
+	assert.Empty(t, a.DirectionLoc())
+
+	err := a.EnableDirection()
+	assert.Nil(t, err)
+	err = a.EnableDirection()
+	assert.Nil(t, err)
+
+	inf := math.Inf(1)
+	assert.Equal(t, 5, a.ColumnSize())
+	assert.Equal(t, []string{"xp", "xn", "xc", "xe", "xd"}, a.ColumnNames())
+	assert.Equal(t, []int{0, 0, 0, 0, 1}, a.Integrality())
+	assert.Equal(t, [2]float64{0, 1}, a.Bounds()[4])
+
+	ac := a.Constraints()
+	assert.Len(t, ac, 2)
+	assert.Equal(t, []float64{-inf, 1, 0, 0, 0, -10, 0}, ac[0], "positive direction constraint malformed")
+	assert.Equal(t, []float64{-inf, 0, 1, 0, 0, 5, 5}, ac[1], "negative direction constraint malformed")
+
+	b := NewBasicUnit(pid, 1, 1, 0.1, 0, inf, 5, 10, 0)
+	err = b.EnableDirection()
+	assert.Error(t, err)
+	assert.Equal(t, 4, b.ColumnSize())
+}
+
+func TestUnitDirectionSolve(t *testing.T) {
+	// negative costs reward simultaneous positive and negative power
+	pid, _ := uuid.NewUUID()
+	a := NewBasicUnit(pid, -1, -2, 0, 0, 10, 5, 10, 0)
+	err := a.EnableDirection()
+	assert.Nil(t, err)
+
+	g := NewGroup(a)
+	err = g.NewSparseConstraint(NetLoadConstraint(&g, 0))
+	assert.Nil(t, err)
+	cl := NewCluster(g, g)
+	s := NewSeries(cl, cl)
+	assert.Equal(t, []int{0, 0, 0, 0, 1}, g.Integrality())
+	assert.Equal(t, 4, sumInts(s.Integrality()))
+
+	res, err := NativeSolver{}.SolveMip(s, SolverOptions{})
+	assert.Nil(t, err)
+	for k := 0; k < 4; k++ {
+		xp, xn := res.Columns[5*k], res.Columns[5*k+1]
+		assert.True(t, xp < 1e-9 || xn < 1e-9, "simultaneous positive and negative power: %v, %v", xp, xn)
+	}
+
+	// the power bounds are the big-M of the direction constraints, so may only be lowered
+	pLoc := s.RealPositivePowerPidLoc(pid)
+	assert.Nil(t, s.SetUpperBoundProfile(pLoc, []float64{10, 4, 0, 10}))
+	assert.Error(t, s.SetUpperBoundProfile(pLoc[1:2], []float64{12}), "upper bound above direction limit accepted")
+	assert.Error(t, s.SetUpperBoundProfile(s.RealNegativePowerPidLoc(pid)[3:], []float64{6}), "upper bound above direction limit accepted")
+	assert.Equal(t, [2]float64{0, 4}, s.Bounds()[pLoc[1]])
+}
+
+func sumInts(t_x []int) int {
+	var s int
+	for _, x := range t_x {
+		s += x
+	}
+	return s
+}
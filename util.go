@@ -1,4 +1,5 @@
 package cgc_optimize
+
 // lb returns the lower bounds of a constraint
 func lb(c []float64) float64 {
 	return c[0]
@@ -13,8 +14,3 @@ func ub(c []float64) float64 {
 func cons(c []float64) []float64 {
 	return c[1 : len(c)-1]
 }
-
-// returns a bounded constraint []float64{lb, cons..., ub}
-func boundConstraint(cons []float64, lb float64, ub float64) []float64 {
-	return append(append([]float64{lb}, cons...), ub)
-}